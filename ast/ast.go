@@ -93,6 +93,13 @@ func (ls *ConstStatement) String() string {
 type Identifier struct {
 	Token token.Token
 	Value string
+
+	// Resolved is set by the resolver once it has found the scope
+	// declaring this name: Depth is the number of environments between
+	// the reference and that scope, so the evaluator can go straight to
+	// it.
+	Resolved bool
+	Depth    int
 }
 
 func (i *Identifier) expressionNode()      {}
@@ -119,6 +126,46 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+// YieldStatement hands a value to whatever is iterating over the
+// generator running it.
+type YieldStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (ys *YieldStatement) statementNode() {}
+
+func (ys *YieldStatement) TokenLiteral() string { return ys.Token.Literal }
+func (ys *YieldStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ys.TokenLiteral() + " ")
+	if ys.Value != nil {
+		out.WriteString(ys.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
+// DeferStatement schedules an expression to be evaluated once the
+// enclosing function returns.
+type DeferStatement struct {
+	Token token.Token
+	Call  Expression
+}
+
+func (ds *DeferStatement) statementNode() {}
+
+func (ds *DeferStatement) TokenLiteral() string { return ds.Token.Literal }
+func (ds *DeferStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ds.TokenLiteral() + " ")
+	if ds.Call != nil {
+		out.WriteString(ds.Call.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
+
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
@@ -178,6 +225,34 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+// SpreadExpression passes the elements of an array as separate
+// arguments of a call, or the pairs of a hash as keyword arguments.
+type SpreadExpression struct {
+	Token token.Token
+
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode() {}
+
+func (se *SpreadExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SpreadExpression) String() string       { return "..." + se.Value.String() }
+
+// KeywordArgument passes an argument to the parameter with the given
+// name, rather than by position.
+type KeywordArgument struct {
+	Token token.Token
+
+	Name string
+
+	Value Expression
+}
+
+func (ka *KeywordArgument) expressionNode() {}
+
+func (ka *KeywordArgument) TokenLiteral() string { return ka.Token.Literal }
+func (ka *KeywordArgument) String() string       { return ka.Name + ": " + ka.Value.String() }
+
 type InfixExpression struct {
 	Token token.Token
 
@@ -261,6 +336,27 @@ func (bs *BlockStatement) String() string {
 	return out.String()
 }
 
+// InputBlock is a BEGIN_INPUT or END_INPUT block, run before the first
+// or after the last line of input when processing lines with -n or -p.
+type InputBlock struct {
+	Token token.Token
+
+	Body *BlockStatement
+}
+
+func (ib *InputBlock) statementNode() {}
+
+func (ib *InputBlock) TokenLiteral() string { return ib.Token.Literal }
+
+func (ib *InputBlock) String() string {
+	var out bytes.Buffer
+	out.WriteString(ib.TokenLiteral())
+	out.WriteString(" {")
+	out.WriteString(ib.Body.String())
+	out.WriteString("}")
+	return out.String()
+}
+
 type IfExpression struct {
 	Token token.Token
 
@@ -304,6 +400,9 @@ type ForeachStatement struct {
 
 	Ident string
 
+	// Pattern, if set, destructures each value in place of Ident.
+	Pattern Expression
+
 	Value Expression
 
 	Body *BlockStatement
@@ -316,7 +415,11 @@ func (fes *ForeachStatement) TokenLiteral() string { return fes.Token.Literal }
 func (fes *ForeachStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("foreach ")
-	out.WriteString(fes.Ident)
+	if fes.Pattern != nil {
+		out.WriteString(fes.Pattern.String())
+	} else {
+		out.WriteString(fes.Ident)
+	}
 	out.WriteString(" ")
 	out.WriteString(fes.Value.String())
 	out.WriteString(fes.Body.String())
@@ -362,6 +465,64 @@ func (fle *ForLoopExpression) String() string {
 	return out.String()
 }
 
+// CountedForExpression is a C-style FOR loop.  Init, Condition and Post
+// may each be nil; a variable declared by Init with LET belongs to the
+// loop.
+type CountedForExpression struct {
+	Token token.Token
+
+	Init Statement
+
+	Condition Expression
+
+	Post Expression
+
+	Body *BlockStatement
+}
+
+func (cfe *CountedForExpression) expressionNode() {}
+
+func (cfe *CountedForExpression) TokenLiteral() string { return cfe.Token.Literal }
+func (cfe *CountedForExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("for (")
+	if cfe.Init != nil {
+		out.WriteString(cfe.Init.String())
+	}
+	out.WriteString("; ")
+	if cfe.Condition != nil {
+		out.WriteString(cfe.Condition.String())
+	}
+	out.WriteString("; ")
+	if cfe.Post != nil {
+		out.WriteString(cfe.Post.String())
+	}
+	out.WriteString(") {")
+	out.WriteString(cfe.Body.String())
+	out.WriteString("}")
+	return out.String()
+}
+
+// BreakStatement ends the innermost loop.
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) String() string       { return bs.TokenLiteral() + ";" }
+
+// ContinueStatement moves on to the next iteration of the innermost loop.
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) String() string       { return cs.TokenLiteral() + ";" }
+
 type FunctionLiteral struct {
 	Token token.Token
 
@@ -369,7 +530,18 @@ type FunctionLiteral struct {
 
 	Defaults map[string]Expression
 
+	// Patterns holds the pattern each argument is destructured with,
+	// by the name of the parameter standing in for it.
+	Patterns map[string]Expression
+
+	// Variadic is set when the last parameter collects any further
+	// arguments into an array.
+	Variadic bool
+
 	Body *BlockStatement
+
+	// Generator is set when Body contains YIELD.
+	Generator bool
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -382,6 +554,9 @@ func (fl *FunctionLiteral) String() string {
 	for _, p := range fl.Parameters {
 		params = append(params, p.String())
 	}
+	if fl.Variadic {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -398,7 +573,18 @@ type FunctionDefineLiteral struct {
 
 	Defaults map[string]Expression
 
+	// Patterns holds the pattern each argument is destructured with,
+	// by the name of the parameter standing in for it.
+	Patterns map[string]Expression
+
+	// Variadic is set when the last parameter collects any further
+	// arguments into an array.
+	Variadic bool
+
 	Body *BlockStatement
+
+	// Generator is set when Body contains YIELD.
+	Generator bool
 }
 
 func (fl *FunctionDefineLiteral) expressionNode() {}
@@ -413,6 +599,9 @@ func (fl *FunctionDefineLiteral) String() string {
 	for _, p := range fl.Parameters {
 		params = append(params, p.String())
 	}
+	if fl.Variadic {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
@@ -603,6 +792,9 @@ type CaseExpression struct {
 
 	Expr []Expression
 
+	// Guard, if set, must be true for the case to match.
+	Guard Expression
+
 	Block *BlockStatement
 }
 
@@ -623,6 +815,10 @@ func (ce *CaseExpression) String() string {
 			tmp = append(tmp, exp.String())
 		}
 		out.WriteString(strings.Join(tmp, ","))
+		if ce.Guard != nil {
+			out.WriteString(" if ")
+			out.WriteString(ce.Guard.String())
+		}
 	}
 	out.WriteString(ce.Block.String())
 	return out.String()
@@ -0,0 +1,170 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"scream/token"
+)
+
+var (
+	nodeType  = reflect.TypeOf((*Node)(nil)).Elem()
+	tokenType = reflect.TypeOf(token.Token{})
+)
+
+// Dump converts a node, and everything beneath it, into plain maps,
+// slices and scalars.  Every node becomes a map holding its "type",
+// source position and fields, keyed by the lower-cased field name.
+func Dump(node Node) interface{} {
+	return dumpValue(reflect.ValueOf(node))
+}
+
+// ToJSON serializes a node as indented JSON with stable key order.
+func ToJSON(node Node) ([]byte, error) {
+	return json.MarshalIndent(Dump(node), "", "  ")
+}
+
+// ToText renders a node as an indented, human-readable tree.
+func ToText(node Node) string {
+	var out bytes.Buffer
+	writeText(&out, Dump(node), 0)
+	return out.String()
+}
+
+func dumpValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Interface {
+			return dumpValue(v.Elem())
+		}
+		return dumpStruct(v.Elem(), v.Type().Implements(nodeType))
+	case reflect.Struct:
+		return dumpStruct(v, false)
+	case reflect.Slice:
+		list := make([]interface{}, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			list[i] = dumpValue(v.Index(i))
+		}
+		return list
+	case reflect.Map:
+		return dumpMap(v)
+	default:
+		return v.Interface()
+	}
+}
+
+func dumpStruct(v reflect.Value, isNode bool) map[string]interface{} {
+	out := make(map[string]interface{})
+	if isNode {
+		out["type"] = v.Type().Name()
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if field.Type == tokenType {
+			tok := v.Field(i).Interface().(token.Token)
+			out["token"] = string(tok.Type)
+			out["literal"] = tok.Literal
+			out["line"] = tok.Line
+			out["column"] = tok.Column
+			continue
+		}
+		out[lowerFirst(field.Name)] = dumpValue(v.Field(i))
+	}
+	return out
+}
+
+// dumpMap emits string-keyed maps as objects, and node-keyed maps (the
+// pairs of a hash literal) as a list of key/value entries in source order.
+func dumpMap(v reflect.Value) interface{} {
+	keys := v.MapKeys()
+	if v.Type().Key().Kind() == reflect.String {
+		out := make(map[string]interface{})
+		for _, k := range keys {
+			out[k.String()] = dumpValue(v.MapIndex(k))
+		}
+		return out
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return nodeLess(keys[i].Interface(), keys[j].Interface())
+	})
+	list := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, map[string]interface{}{
+			"key":   dumpValue(k),
+			"value": dumpValue(v.MapIndex(k)),
+		})
+	}
+	return list
+}
+
+func nodeLess(a, b interface{}) bool {
+	na, okA := a.(Node)
+	nb, okB := b.(Node)
+	if !okA || !okB {
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	}
+	ta, tb := TokenOf(na), TokenOf(nb)
+	if ta.Line != tb.Line {
+		return ta.Line < tb.Line
+	}
+	if ta.Column != tb.Column {
+		return ta.Column < tb.Column
+	}
+	return na.String() < nb.String()
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+func writeText(out *bytes.Buffer, v interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if name, ok := v["type"]; ok {
+			if _, ok := v["line"]; ok {
+				fmt.Fprintf(out, "%s%s %v:%v %q\n", indent, name, v["line"], v["column"], v["literal"])
+			} else {
+				fmt.Fprintf(out, "%s%s\n", indent, name)
+			}
+		}
+		var keys []string
+		for k := range v {
+			switch k {
+			case "type", "token", "literal", "line", "column":
+				continue
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch child := v[k].(type) {
+			case map[string]interface{}, []interface{}:
+				fmt.Fprintf(out, "%s  %s:\n", indent, k)
+				writeText(out, child, depth+2)
+			default:
+				fmt.Fprintf(out, "%s  %s: %v\n", indent, k, child)
+			}
+		}
+	case []interface{}:
+		for _, e := range v {
+			writeText(out, e, depth)
+		}
+	default:
+		fmt.Fprintf(out, "%s%v\n", indent, v)
+	}
+}
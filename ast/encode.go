@@ -0,0 +1,74 @@
+package ast
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// Every node type which may appear in a program must be registered so
+// that it can be carried in the Statement and Expression interfaces.
+func init() {
+	for _, node := range []Node{
+		&Program{},
+		&LetStatement{},
+		&DestructuringStatement{},
+		&ArrayPattern{},
+		&HashPattern{},
+		&TypePattern{},
+		&VariablePattern{},
+		&ConstStatement{},
+		&Identifier{},
+		&ReturnStatement{},
+		&YieldStatement{},
+		&DeferStatement{},
+		&ExpressionStatement{},
+		&IntegerLiteral{},
+		&FloatLiteral{},
+		&PrefixExpression{},
+		&SpreadExpression{},
+		&KeywordArgument{},
+		&InfixExpression{},
+		&PostfixExpression{},
+		&NullLiteral{},
+		&Boolean{},
+		&BlockStatement{},
+		&InputBlock{},
+		&IfExpression{},
+		&TernaryExpression{},
+		&ForeachStatement{},
+		&ForLoopExpression{},
+		&CountedForExpression{},
+		&BreakStatement{},
+		&ContinueStatement{},
+		&FunctionLiteral{},
+		&FunctionDefineLiteral{},
+		&CallExpression{},
+		&ObjectCallExpression{},
+		&StringLiteral{},
+		&RegexpLiteral{},
+		&BacktickLiteral{},
+		&ArrayLiteral{},
+		&IndexExpression{},
+		&HashLiteral{},
+		&AssignStatement{},
+		&CaseExpression{},
+		&SwitchExpression{},
+	} {
+		gob.Register(node)
+	}
+}
+
+// Encode writes a binary serialization of program to w, which Decode
+// reads back.  The program must have parsed without errors.
+func Encode(w io.Writer, program *Program) error {
+	return gob.NewEncoder(w).Encode(program)
+}
+
+// Decode reads a program written by Encode.
+func Decode(r io.Reader) (*Program, error) {
+	program := &Program{}
+	if err := gob.NewDecoder(r).Decode(program); err != nil {
+		return nil, err
+	}
+	return program, nil
+}
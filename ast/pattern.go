@@ -0,0 +1,182 @@
+package ast
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"scream/token"
+)
+
+// ArrayPattern binds names to the elements of an array, in order.
+// Each element is an *Identifier, a nested pattern, or any other
+// expression, whose value the element must equal.
+type ArrayPattern struct {
+	Token token.Token
+
+	Elements []Expression
+
+	// Defaults holds the values of identifiers with no element to bind.
+	Defaults map[string]Expression
+
+	// Rest, if set, is bound to an array of any further elements.
+	Rest *Identifier
+}
+
+func (ap *ArrayPattern) expressionNode() {}
+
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+
+func (ap *ArrayPattern) String() string {
+	var parts []string
+	for _, e := range ap.Elements {
+		parts = append(parts, patternTarget(e, ap.Defaults))
+	}
+	if ap.Rest != nil {
+		parts = append(parts, "..."+ap.Rest.String())
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// HashPattern binds names to the values of a hash with the given keys.
+// Each target is an *Identifier, a nested pattern, or a value to equal.
+type HashPattern struct {
+	Token token.Token
+
+	Keys []string
+
+	Targets []Expression
+
+	// Defaults holds the values of identifiers whose key is missing.
+	Defaults map[string]Expression
+
+	// Rest, if set, is bound to a hash of any other pairs.
+	Rest *Identifier
+}
+
+func (hp *HashPattern) expressionNode() {}
+
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+
+func (hp *HashPattern) String() string {
+	var parts []string
+	for i, key := range hp.Keys {
+		if id, ok := hp.Targets[i].(*Identifier); ok && id.Value == key {
+			parts = append(parts, patternTarget(id, hp.Defaults))
+			continue
+		}
+		if !isIdentifier(key) {
+			key = strconv.Quote(key)
+		}
+		parts = append(parts, key+": "+patternTarget(hp.Targets[i], hp.Defaults))
+	}
+	if hp.Rest != nil {
+		parts = append(parts, "..."+hp.Rest.String())
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func patternTarget(target Expression, defaults map[string]Expression) string {
+	var out bytes.Buffer
+	out.WriteString(target.String())
+	if id, ok := target.(*Identifier); ok {
+		if def, ok := defaults[id.Value]; ok {
+			out.WriteString(" = ")
+			out.WriteString(def.String())
+		}
+	}
+	return out.String()
+}
+
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// TypePattern is a switch case matching every value of the type named,
+// as type() reports it.
+type TypePattern struct {
+	Token token.Token
+
+	Name string
+}
+
+func (tp *TypePattern) expressionNode() {}
+
+func (tp *TypePattern) TokenLiteral() string { return tp.Token.Literal }
+
+func (tp *TypePattern) String() string { return tp.Name }
+
+// VariablePattern is a switch case matching any value, which it binds to
+// Name.
+type VariablePattern struct {
+	Token token.Token
+
+	Name *Identifier
+}
+
+func (vp *VariablePattern) expressionNode() {}
+
+func (vp *VariablePattern) TokenLiteral() string { return vp.Token.Literal }
+
+func (vp *VariablePattern) String() string { return vp.Name.String() }
+
+// PatternNames returns the identifiers a pattern binds, in source order.
+func PatternNames(pattern Expression) []*Identifier {
+	var names []*Identifier
+	var collect func(Expression)
+	collect = func(e Expression) {
+		switch e := e.(type) {
+		case *Identifier:
+			names = append(names, e)
+		case *VariablePattern:
+			names = append(names, e.Name)
+		case *ArrayPattern:
+			for _, el := range e.Elements {
+				collect(el)
+			}
+			if e.Rest != nil {
+				names = append(names, e.Rest)
+			}
+		case *HashPattern:
+			for _, t := range e.Targets {
+				collect(t)
+			}
+			if e.Rest != nil {
+				names = append(names, e.Rest)
+			}
+		}
+	}
+	collect(pattern)
+	return names
+}
+
+// DestructuringStatement is a LET which binds the names in a pattern to
+// the parts of a value.
+type DestructuringStatement struct {
+	Token token.Token
+
+	Pattern Expression
+
+	Value Expression
+}
+
+func (ds *DestructuringStatement) statementNode() {}
+
+func (ds *DestructuringStatement) TokenLiteral() string { return ds.Token.Literal }
+
+func (ds *DestructuringStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(ds.TokenLiteral() + " ")
+	out.WriteString(ds.Pattern.String())
+	out.WriteString(" = ")
+	if ds.Value != nil {
+		out.WriteString(ds.Value.String())
+	}
+	out.WriteString(";")
+	return out.String()
+}
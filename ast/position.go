@@ -0,0 +1,22 @@
+package ast
+
+import (
+	"reflect"
+
+	"scream/token"
+)
+
+// TokenOf returns the token a node was parsed from, which carries the
+// node's source position.  Nodes without a token yield the zero token.
+func TokenOf(node Node) token.Token {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return token.Token{}
+	}
+	f := v.Elem().FieldByName("Token")
+	if !f.IsValid() {
+		return token.Token{}
+	}
+	tok, _ := f.Interface().(token.Token)
+	return tok
+}
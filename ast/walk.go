@@ -0,0 +1,418 @@
+package ast
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Visitor is called by Walk for every node.  If the returned visitor is
+// non-nil, Walk visits each child of the node with it, followed by a
+// call of Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree rooted at node in depth-first, source order.
+func Walk(v Visitor, node Node) {
+	if isNil(node) {
+		return
+	}
+	if v = v.Visit(node); v == nil {
+		return
+	}
+	walkChildren(v, node)
+	v.Visit(nil)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect calls f for every node in the tree rooted at node.  Children
+// are only visited while f returns true; after the children of a node
+// have been visited f is called with nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+func walkChildren(v Visitor, node Node) {
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			Walk(v, s)
+		}
+	case *LetStatement:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+	case *DestructuringStatement:
+		Walk(v, n.Pattern)
+		Walk(v, n.Value)
+	case *ArrayPattern:
+		walkTargets(v, n.Elements, n.Defaults)
+		Walk(v, n.Rest)
+	case *HashPattern:
+		walkTargets(v, n.Targets, n.Defaults)
+		Walk(v, n.Rest)
+	case *ConstStatement:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+	case *ReturnStatement:
+		Walk(v, n.ReturnValue)
+	case *YieldStatement:
+		Walk(v, n.Value)
+	case *DeferStatement:
+		Walk(v, n.Call)
+	case *ExpressionStatement:
+		Walk(v, n.Expression)
+	case *PrefixExpression:
+		Walk(v, n.Right)
+	case *SpreadExpression:
+		Walk(v, n.Value)
+	case *KeywordArgument:
+		Walk(v, n.Value)
+	case *InfixExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+	case *BlockStatement:
+		for _, s := range n.Statements {
+			Walk(v, s)
+		}
+	case *InputBlock:
+		Walk(v, n.Body)
+	case *IfExpression:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+		Walk(v, n.Alternative)
+	case *TernaryExpression:
+		Walk(v, n.Condition)
+		Walk(v, n.IfTrue)
+		Walk(v, n.IfFalse)
+	case *ForeachStatement:
+		Walk(v, n.Pattern)
+		Walk(v, n.Value)
+		Walk(v, n.Body)
+	case *ForLoopExpression:
+		Walk(v, n.Condition)
+		Walk(v, n.Consequence)
+	case *CountedForExpression:
+		Walk(v, n.Init)
+		Walk(v, n.Condition)
+		Walk(v, n.Post)
+		Walk(v, n.Body)
+	case *FunctionLiteral:
+		walkParameters(v, n.Parameters, n.Defaults, n.Patterns)
+		Walk(v, n.Body)
+	case *FunctionDefineLiteral:
+		walkParameters(v, n.Parameters, n.Defaults, n.Patterns)
+		Walk(v, n.Body)
+	case *CallExpression:
+		Walk(v, n.Function)
+		for _, a := range n.Arguments {
+			Walk(v, a)
+		}
+	case *ObjectCallExpression:
+		Walk(v, n.Object)
+		Walk(v, n.Call)
+	case *ArrayLiteral:
+		for _, e := range n.Elements {
+			Walk(v, e)
+		}
+	case *IndexExpression:
+		Walk(v, n.Left)
+		Walk(v, n.Index)
+	case *HashLiteral:
+		for _, key := range sortedKeys(n.Pairs) {
+			Walk(v, key)
+			Walk(v, n.Pairs[key])
+		}
+	case *AssignStatement:
+		Walk(v, n.Name)
+		Walk(v, n.Value)
+	case *VariablePattern:
+		Walk(v, n.Name)
+	case *CaseExpression:
+		for _, e := range n.Expr {
+			Walk(v, e)
+		}
+		Walk(v, n.Guard)
+		Walk(v, n.Block)
+	case *SwitchExpression:
+		Walk(v, n.Value)
+		for _, c := range n.Choices {
+			Walk(v, c)
+		}
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *PostfixExpression,
+		*NullLiteral, *Boolean, *StringLiteral, *RegexpLiteral, *BacktickLiteral,
+		*BreakStatement, *ContinueStatement, *TypePattern:
+		// leaves
+	default:
+		panic(fmt.Sprintf("ast.Walk: unexpected node type %T", n))
+	}
+}
+
+// walkParameters visits each parameter followed by its pattern and its
+// default, if any.
+func walkParameters(v Visitor, params []*Identifier, defaults map[string]Expression, patterns map[string]Expression) {
+	for _, p := range params {
+		Walk(v, p)
+		if pat, ok := patterns[p.Value]; ok {
+			Walk(v, pat)
+		}
+		if def, ok := defaults[p.Value]; ok {
+			Walk(v, def)
+		}
+	}
+}
+
+// walkTargets visits each target of a pattern followed by its default,
+// if any.
+func walkTargets(v Visitor, targets []Expression, defaults map[string]Expression) {
+	for _, t := range targets {
+		Walk(v, t)
+		if id, ok := t.(*Identifier); ok {
+			if def, ok := defaults[id.Value]; ok {
+				Walk(v, def)
+			}
+		}
+	}
+}
+
+// Rewrite traverses the tree rooted at node depth-first and replaces
+// every node with the result of calling fn on it, after its children
+// have been rewritten.  fn must return a node that fits the slot it
+// came from: an Expression for an Expression, a *BlockStatement for a
+// block, and so on; returning the node unchanged keeps it.
+func Rewrite(node Node, fn func(Node) Node) Node {
+	if isNil(node) {
+		return node
+	}
+	rewriteChildren(node, fn)
+	return fn(node)
+}
+
+func rewriteChildren(node Node, fn func(Node) Node) {
+	switch n := node.(type) {
+	case *Program:
+		for i, s := range n.Statements {
+			n.Statements[i] = rewriteStatement(s, fn)
+		}
+	case *LetStatement:
+		n.Name = rewriteIdentifier(n.Name, fn)
+		n.Value = rewriteExpression(n.Value, fn)
+	case *DestructuringStatement:
+		n.Pattern = rewriteExpression(n.Pattern, fn)
+		n.Value = rewriteExpression(n.Value, fn)
+	case *ArrayPattern:
+		rewriteTargets(n.Elements, n.Defaults, fn)
+		n.Rest = rewriteIdentifier(n.Rest, fn)
+	case *HashPattern:
+		rewriteTargets(n.Targets, n.Defaults, fn)
+		n.Rest = rewriteIdentifier(n.Rest, fn)
+	case *ConstStatement:
+		n.Name = rewriteIdentifier(n.Name, fn)
+		n.Value = rewriteExpression(n.Value, fn)
+	case *ReturnStatement:
+		n.ReturnValue = rewriteExpression(n.ReturnValue, fn)
+	case *YieldStatement:
+		n.Value = rewriteExpression(n.Value, fn)
+	case *DeferStatement:
+		n.Call = rewriteExpression(n.Call, fn)
+	case *ExpressionStatement:
+		n.Expression = rewriteExpression(n.Expression, fn)
+	case *PrefixExpression:
+		n.Right = rewriteExpression(n.Right, fn)
+	case *SpreadExpression:
+		n.Value = rewriteExpression(n.Value, fn)
+	case *KeywordArgument:
+		n.Value = rewriteExpression(n.Value, fn)
+	case *InfixExpression:
+		n.Left = rewriteExpression(n.Left, fn)
+		n.Right = rewriteExpression(n.Right, fn)
+	case *BlockStatement:
+		for i, s := range n.Statements {
+			n.Statements[i] = rewriteStatement(s, fn)
+		}
+	case *InputBlock:
+		n.Body = rewriteBlock(n.Body, fn)
+	case *IfExpression:
+		n.Condition = rewriteExpression(n.Condition, fn)
+		n.Consequence = rewriteBlock(n.Consequence, fn)
+		n.Alternative = rewriteBlock(n.Alternative, fn)
+	case *TernaryExpression:
+		n.Condition = rewriteExpression(n.Condition, fn)
+		n.IfTrue = rewriteExpression(n.IfTrue, fn)
+		n.IfFalse = rewriteExpression(n.IfFalse, fn)
+	case *ForeachStatement:
+		n.Pattern = rewriteExpression(n.Pattern, fn)
+		n.Value = rewriteExpression(n.Value, fn)
+		n.Body = rewriteBlock(n.Body, fn)
+	case *ForLoopExpression:
+		n.Condition = rewriteExpression(n.Condition, fn)
+		n.Consequence = rewriteBlock(n.Consequence, fn)
+	case *CountedForExpression:
+		n.Init = rewriteStatement(n.Init, fn)
+		n.Condition = rewriteExpression(n.Condition, fn)
+		n.Post = rewriteExpression(n.Post, fn)
+		n.Body = rewriteBlock(n.Body, fn)
+	case *FunctionLiteral:
+		rewriteParameters(n.Parameters, n.Defaults, n.Patterns, fn)
+		n.Body = rewriteBlock(n.Body, fn)
+	case *FunctionDefineLiteral:
+		rewriteParameters(n.Parameters, n.Defaults, n.Patterns, fn)
+		n.Body = rewriteBlock(n.Body, fn)
+	case *CallExpression:
+		n.Function = rewriteExpression(n.Function, fn)
+		for i, a := range n.Arguments {
+			n.Arguments[i] = rewriteExpression(a, fn)
+		}
+	case *ObjectCallExpression:
+		n.Object = rewriteExpression(n.Object, fn)
+		n.Call = rewriteExpression(n.Call, fn)
+	case *ArrayLiteral:
+		for i, e := range n.Elements {
+			n.Elements[i] = rewriteExpression(e, fn)
+		}
+	case *IndexExpression:
+		n.Left = rewriteExpression(n.Left, fn)
+		n.Index = rewriteExpression(n.Index, fn)
+	case *HashLiteral:
+		pairs := make(map[Expression]Expression, len(n.Pairs))
+		for _, key := range sortedKeys(n.Pairs) {
+			value := n.Pairs[key]
+			pairs[rewriteExpression(key, fn)] = rewriteExpression(value, fn)
+		}
+		n.Pairs = pairs
+	case *AssignStatement:
+		n.Name = rewriteIdentifier(n.Name, fn)
+		n.Value = rewriteExpression(n.Value, fn)
+	case *VariablePattern:
+		n.Name = rewriteIdentifier(n.Name, fn)
+	case *CaseExpression:
+		for i, e := range n.Expr {
+			n.Expr[i] = rewriteExpression(e, fn)
+		}
+		n.Guard = rewriteExpression(n.Guard, fn)
+		n.Block = rewriteBlock(n.Block, fn)
+	case *SwitchExpression:
+		n.Value = rewriteExpression(n.Value, fn)
+		for i, c := range n.Choices {
+			if r, ok := Rewrite(c, fn).(*CaseExpression); ok {
+				n.Choices[i] = r
+			} else {
+				panic(fmt.Sprintf("ast.Rewrite: %T cannot replace a switch case", r))
+			}
+		}
+	case *Identifier, *IntegerLiteral, *FloatLiteral, *PostfixExpression,
+		*NullLiteral, *Boolean, *StringLiteral, *RegexpLiteral, *BacktickLiteral,
+		*BreakStatement, *ContinueStatement, *TypePattern:
+		// leaves
+	default:
+		panic(fmt.Sprintf("ast.Rewrite: unexpected node type %T", n))
+	}
+}
+
+func rewriteParameters(params []*Identifier, defaults map[string]Expression, patterns map[string]Expression, fn func(Node) Node) {
+	for i, p := range params {
+		def, ok := defaults[p.Value]
+		pat, patterned := patterns[p.Value]
+		params[i] = rewriteIdentifier(p, fn)
+		if patterned {
+			delete(patterns, p.Value)
+			patterns[params[i].Value] = rewriteExpression(pat, fn)
+		}
+		if ok {
+			delete(defaults, p.Value)
+			defaults[params[i].Value] = rewriteExpression(def, fn)
+		}
+	}
+}
+
+func rewriteTargets(targets []Expression, defaults map[string]Expression, fn func(Node) Node) {
+	for i, t := range targets {
+		id, ok := t.(*Identifier)
+		var def Expression
+		if ok {
+			def, ok = defaults[id.Value]
+		}
+		targets[i] = rewriteExpression(t, fn)
+		if ok {
+			delete(defaults, id.Value)
+			if id, named := targets[i].(*Identifier); named {
+				defaults[id.Value] = rewriteExpression(def, fn)
+			}
+		}
+	}
+}
+
+func rewriteExpression(e Expression, fn func(Node) Node) Expression {
+	if isNil(e) {
+		return e
+	}
+	r := Rewrite(e, fn)
+	if x, ok := r.(Expression); ok {
+		return x
+	}
+	panic(fmt.Sprintf("ast.Rewrite: %T cannot replace expression %T", r, e))
+}
+
+func rewriteStatement(s Statement, fn func(Node) Node) Statement {
+	if isNil(s) {
+		return s
+	}
+	r := Rewrite(s, fn)
+	if x, ok := r.(Statement); ok {
+		return x
+	}
+	panic(fmt.Sprintf("ast.Rewrite: %T cannot replace statement %T", r, s))
+}
+
+func rewriteBlock(b *BlockStatement, fn func(Node) Node) *BlockStatement {
+	if b == nil {
+		return nil
+	}
+	r := Rewrite(b, fn)
+	if x, ok := r.(*BlockStatement); ok {
+		return x
+	}
+	panic(fmt.Sprintf("ast.Rewrite: %T cannot replace a block", r))
+}
+
+func rewriteIdentifier(i *Identifier, fn func(Node) Node) *Identifier {
+	if i == nil {
+		return nil
+	}
+	r := Rewrite(i, fn)
+	if x, ok := r.(*Identifier); ok {
+		return x
+	}
+	panic(fmt.Sprintf("ast.Rewrite: %T cannot replace identifier %s", r, i.Value))
+}
+
+// sortedKeys orders the keys of a hash literal by source position so
+// that traversal is deterministic.
+func sortedKeys(pairs map[Expression]Expression) []Expression {
+	keys := make([]Expression, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return nodeLess(keys[i], keys[j])
+	})
+	return keys
+}
+
+// isNil reports whether node is nil, including a typed nil pointer
+// held in the interface.
+func isNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
@@ -0,0 +1,180 @@
+package ast_test
+
+import (
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scream/ast"
+	"scream/lexer"
+	"scream/parser"
+)
+
+// nodeTypes returns the name of every type in this package which
+// implements ast.Node, found by scanning the source for TokenLiteral
+// methods.
+func nodeTypes(t *testing.T) map[string]bool {
+	fset := gotoken.NewFileSet()
+	pkgs, err := goparser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("parsing package: %s", err)
+	}
+
+	types := make(map[string]bool)
+	for _, file := range pkgs["ast"].Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*goast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Name.Name != "TokenLiteral" {
+				continue
+			}
+			star := fn.Recv.List[0].Type.(*goast.StarExpr)
+			types[star.X.(*goast.Ident).Name] = true
+		}
+	}
+	return types
+}
+
+// switchCases returns the type names listed in the case clauses of the
+// type switch inside the named function of the given file.
+func switchCases(t *testing.T, path string, function string) map[string]bool {
+	fset := gotoken.NewFileSet()
+	file, err := goparser.ParseFile(fset, filepath.Clean(path), nil, 0)
+	if err != nil {
+		t.Fatalf("parsing %s: %s", path, err)
+	}
+
+	cases := make(map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*goast.FuncDecl)
+		if !ok || fn.Name.Name != function {
+			continue
+		}
+		goast.Inspect(fn, func(n goast.Node) bool {
+			clause, ok := n.(*goast.CaseClause)
+			if !ok {
+				return true
+			}
+			for _, e := range clause.List {
+				if star, ok := e.(*goast.StarExpr); ok {
+					cases[star.X.(*goast.Ident).Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return cases
+}
+
+// registeredTypes returns the type names of the &T{} literals in the
+// named function of the given file.
+func registeredTypes(t *testing.T, path string, function string) map[string]bool {
+	fset := gotoken.NewFileSet()
+	file, err := goparser.ParseFile(fset, filepath.Clean(path), nil, 0)
+	if err != nil {
+		t.Fatalf("parsing %s: %s", path, err)
+	}
+
+	types := make(map[string]bool)
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*goast.FuncDecl)
+		if !ok || fn.Name.Name != function {
+			continue
+		}
+		goast.Inspect(fn, func(n goast.Node) bool {
+			unary, ok := n.(*goast.UnaryExpr)
+			if !ok || unary.Op != gotoken.AND {
+				return true
+			}
+			if lit, ok := unary.X.(*goast.CompositeLit); ok {
+				if name, ok := lit.Type.(*goast.Ident); ok {
+					types[name.Name] = true
+				}
+			}
+			return true
+		})
+	}
+	return types
+}
+
+func TestNodeTypesAreHandled(t *testing.T) {
+	types := nodeTypes(t)
+	if len(types) == 0 {
+		t.Fatalf("found no node types")
+	}
+	for _, function := range []string{"walkChildren", "rewriteChildren"} {
+		cases := switchCases(t, "walk.go", function)
+		for name := range types {
+			if !cases[name] {
+				t.Errorf("%s does not handle *ast.%s", function, name)
+			}
+		}
+	}
+
+	registered := registeredTypes(t, "encode.go", "init")
+	for name := range types {
+		if !registered[name] {
+			t.Errorf("encode.go does not register *ast.%s", name)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parse errors: %v", p.Errors())
+	}
+	return program
+}
+
+func TestInspectVisitsEveryChild(t *testing.T) {
+	program := parse(t, `
+FUNC F(A, B = DEF)
+BEGIN
+    RETURN { "k": KEY, "v": VAL };
+END
+switch (S) {
+    case C1, C2 { PRINT(BODY); }
+    default { PRINT(OTHER); }
+}
+`)
+	seen := make(map[string]bool)
+	ast.Inspect(program, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Identifier); ok {
+			seen[id.Value] = true
+		}
+		return true
+	})
+	for _, name := range []string{"A", "B", "DEF", "KEY", "VAL", "S", "C1", "C2", "BODY", "OTHER", "PRINT"} {
+		if !seen[name] {
+			t.Errorf("identifier %s was not visited", name)
+		}
+	}
+}
+
+func TestRewriteReplacesNodes(t *testing.T) {
+	program := parse(t, `LET A = [1, 2, { "x": 3 }];`)
+	ast.Rewrite(program, func(n ast.Node) ast.Node {
+		if lit, ok := n.(*ast.IntegerLiteral); ok {
+			return &ast.IntegerLiteral{Token: lit.Token, Value: lit.Value * 10}
+		}
+		return n
+	})
+
+	var sum int64
+	ast.Inspect(program, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.IntegerLiteral); ok {
+			sum += lit.Value
+		}
+		return true
+	})
+	if sum != 60 {
+		t.Errorf("rewritten literals sum to %d, want 60", sum)
+	}
+}
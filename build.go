@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scream/bundle"
+	"scream/evaluator"
+	"scream/lexer"
+	"scream/parser"
+)
+
+// build writes a standalone executable made of this interpreter with a
+// script appended, along with every file the script imports by a
+// literal path.  Running the executable runs the script, passing it the
+// command-line arguments.
+func build(args []string) int {
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	out := fs.String("o", "", "Name of the executable to write.")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "usage: scream build [-o tool] script.scream\n")
+		return 2
+	}
+
+	main := filepath.Clean(fs.Arg(0))
+	if *out == "" {
+		*out = strings.TrimSuffix(filepath.Base(main), filepath.Ext(main))
+	}
+
+	b := &bundle.Bundle{Main: main, Files: make(map[string][]byte)}
+	queue := []string{main}
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+		if _, ok := b.Files[path]; ok {
+			continue
+		}
+
+		input, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading: %s\n", err.Error())
+			return 1
+		}
+		p := parser.New(lexer.New(string(input)))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			fmt.Fprintf(os.Stderr, "%s:\n", path)
+			for _, msg := range p.Errors() {
+				fmt.Fprintf(os.Stderr, "\t%s\n", msg)
+			}
+			return 1
+		}
+
+		b.Files[path] = input
+		for _, imp := range evaluator.Imports(program) {
+			queue = append(queue, evaluator.ImportPath(path, imp))
+		}
+	}
+
+	// The script's config is bundled too, so the executable behaves as
+	// the script does when run where it was written.
+	config := filepath.Join(filepath.Dir(main), ConfigFile)
+	if data, err := ioutil.ReadFile(config); err == nil {
+		b.Files[config] = data
+	}
+
+	if err := writeExecutable(*out, b); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
+	}
+	return 0
+}
+
+// writeExecutable copies the running interpreter, without any bundle of
+// its own, to path and appends b.
+func writeExecutable(path string, b *bundle.Bundle) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	_, size, err := bundle.Open(self)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(self)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(out, in, size); err != nil {
+		out.Close()
+		return err
+	}
+	if err := b.Append(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// runBundle runs the script bundled into this executable, passing it
+// args and applying its config as run would.
+func runBundle(b *bundle.Bundle, args []string) int {
+	config := filepath.Join(filepath.Dir(b.Main), ConfigFile)
+	if data, ok := b.Files[config]; ok {
+		if err := applyConfig(config, bytes.NewReader(data)); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			return 1
+		}
+	}
+
+	evaluator.ScriptPath = b.Main
+	evaluator.ReadImport = func(path string) ([]byte, error) {
+		if data, ok := b.Files[path]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("open %s: not bundled", path)
+	}
+	scriptArgs = scriptArguments(args)
+	return Execute(string(b.Files[b.Main]))
+}
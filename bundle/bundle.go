@@ -0,0 +1,138 @@
+package bundle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Magic ends every executable carrying a bundle.
+const Magic = "SCRMBNDL"
+
+// trailerSize is the size of the payload length and Magic which follow
+// the payload.
+const trailerSize = 8 + len(Magic)
+
+// Bundle holds a script and the files it imports, appended to a copy of
+// the interpreter to make a standalone executable.
+type Bundle struct {
+	// Main is the path of the script to run.
+	Main string
+
+	// Files holds the contents of Main and of every imported file.
+	Files map[string][]byte
+}
+
+// Append writes the bundle, followed by the trailer which lets Open find
+// it, to w.  The output depends only on the bundle's contents.
+func (b *Bundle) Append(w io.Writer) error {
+	if _, ok := b.Files[b.Main]; !ok {
+		return fmt.Errorf("bundle does not contain %s", b.Main)
+	}
+
+	names := make([]string, 0, len(b.Files))
+	for name := range b.Files {
+		if name != b.Main {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{b.Main}, names...)
+
+	var payload bytes.Buffer
+	var count [4]byte
+	binary.BigEndian.PutUint32(count[:], uint32(len(names)))
+	payload.Write(count[:])
+	for _, name := range names {
+		writeBytes(&payload, []byte(name))
+		writeBytes(&payload, b.Files[name])
+	}
+
+	if _, err := w.Write(payload.Bytes()); err != nil {
+		return err
+	}
+	var trailer [trailerSize]byte
+	binary.BigEndian.PutUint64(trailer[:8], uint64(payload.Len()))
+	copy(trailer[8:], Magic)
+	_, err := w.Write(trailer[:])
+	return err
+}
+
+func writeBytes(buf *bytes.Buffer, data []byte) {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	buf.Write(size[:])
+	buf.Write(data)
+}
+
+// Open reads the bundle appended to the executable at path.  It returns
+// a nil bundle if there is none, along with the size of the executable
+// without any bundle.
+func Open(path string) (*Bundle, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := info.Size()
+	if size < int64(trailerSize) {
+		return nil, size, nil
+	}
+
+	var trailer [trailerSize]byte
+	if _, err := f.ReadAt(trailer[:], size-int64(trailerSize)); err != nil {
+		return nil, 0, err
+	}
+	if string(trailer[8:]) != Magic {
+		return nil, size, nil
+	}
+	length := int64(binary.BigEndian.Uint64(trailer[:8]))
+	start := size - int64(trailerSize) - length
+	if length < 4 || start < 0 {
+		return nil, 0, errors.New("corrupt bundle")
+	}
+
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, start); err != nil {
+		return nil, 0, err
+	}
+
+	b := &Bundle{Files: make(map[string][]byte)}
+	count := binary.BigEndian.Uint32(payload)
+	payload = payload[4:]
+	for i := uint32(0); i < count; i++ {
+		var name, data []byte
+		if name, payload, err = readBytes(payload); err != nil {
+			return nil, 0, err
+		}
+		if data, payload, err = readBytes(payload); err != nil {
+			return nil, 0, err
+		}
+		if i == 0 {
+			b.Main = string(name)
+		}
+		b.Files[string(name)] = data
+	}
+	return b, start, nil
+}
+
+func readBytes(payload []byte) ([]byte, []byte, error) {
+	if len(payload) < 4 {
+		return nil, nil, errors.New("corrupt bundle")
+	}
+	size := binary.BigEndian.Uint32(payload)
+	payload = payload[4:]
+	if uint64(len(payload)) < uint64(size) {
+		return nil, nil, errors.New("corrupt bundle")
+	}
+	return payload[:size], payload[size:], nil
+}
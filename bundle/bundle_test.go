@@ -0,0 +1,58 @@
+package bundle
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	b := &Bundle{Main: "main.scream", Files: map[string][]byte{
+		"main.scream":     []byte(`import("lib/a.scream");`),
+		"lib/a.scream":    []byte(`LET A = 1;`),
+		"lib/b.scream":    []byte(``),
+		"lib/zzz.scream":  []byte(`LET Z = 26;`),
+		"other/x.scream":  []byte(`PRINT(1);`),
+		"other/yy.scream": []byte(`PRINT(2);`),
+	}}
+	exe := []byte("#!interpreter\n")
+
+	var first, second bytes.Buffer
+	for _, buf := range []*bytes.Buffer{&first, &second} {
+		buf.Write(exe)
+		if err := b.Append(buf); err != nil {
+			t.Fatalf("Append failed: %s", err)
+		}
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Errorf("bundle output is not deterministic")
+	}
+
+	path := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(path, first.Bytes(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	got, size, err := Open(path)
+	if err != nil || got == nil {
+		t.Fatalf("Open gave %v, %v", got, err)
+	}
+	if size != int64(len(exe)) {
+		t.Errorf("interpreter size is %d, want %d", size, len(exe))
+	}
+	if got.Main != b.Main || len(got.Files) != len(b.Files) {
+		t.Errorf("opened %s with %d files", got.Main, len(got.Files))
+	}
+	for name, data := range b.Files {
+		if !bytes.Equal(got.Files[name], data) {
+			t.Errorf("%s is %q, want %q", name, got.Files[name], data)
+		}
+	}
+
+	if err := os.WriteFile(path, exe, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if got, size, err := Open(path); got != nil || err != nil || size != int64(len(exe)) {
+		t.Errorf("plain executable gave %v, %d, %v", got, size, err)
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scream/ast"
+)
+
+// Magic starts every cache file.
+const Magic = "SCREAMC\x00"
+
+// Version is the format of the cache.  It must be increased whenever the
+// parser or the AST changes, so that older caches are parsed again.
+const Version = 8
+
+// Ext is the extension of cache files.
+const Ext = ".screamc"
+
+// ErrStale is returned when a cache does not match its source.
+var ErrStale = errors.New("cache is stale")
+
+type header struct {
+	Magic   [8]byte
+	Version uint32
+	Sum     [sha256.Size]byte
+}
+
+// Path returns the cache file used for the given source file.
+func Path(source string) string {
+	return strings.TrimSuffix(source, filepath.Ext(source)) + Ext
+}
+
+// Write stores program, parsed from source, in the cache file at path.
+func Write(path string, source []byte, program *ast.Program) error {
+	var buf bytes.Buffer
+	h := header{Version: Version, Sum: sha256.Sum256(source)}
+	copy(h.Magic[:], Magic)
+	if err := binary.Write(&buf, binary.BigEndian, &h); err != nil {
+		return err
+	}
+	if err := ast.Encode(&buf, program); err != nil {
+		return fmt.Errorf("encoding %s: %s", path, err)
+	}
+
+	// Write to a temporary file first, so a concurrent run never reads
+	// a partial cache.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Read loads the program in the cache file at path, returning ErrStale
+// if it was written by a different version or for different source.
+func Read(path string, source []byte) (*ast.Program, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	var h header
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("%s is not a cache file", path)
+		}
+		return nil, err
+	}
+	if string(h.Magic[:]) != Magic {
+		return nil, fmt.Errorf("%s is not a cache file", path)
+	}
+	if h.Version != Version || h.Sum != sha256.Sum256(source) {
+		return nil, ErrStale
+	}
+
+	program, err := ast.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %s", path, err)
+	}
+	return program, nil
+}
+
+// Load returns the cached program for the source file at path if there
+// is a fresh one.
+func Load(path string, source []byte) (*ast.Program, bool) {
+	program, err := Read(Path(path), source)
+	return program, err == nil
+}
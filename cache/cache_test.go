@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"scream/lexer"
+	"scream/parser"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	source := []byte(`FUNC F(A, B = 2) BEGIN RETURN A + B; END PRINT(F(1), { "k": [1, 2.5] });`)
+	p := parser.New(lexer.New(string(source)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parse errors: %v", p.Errors())
+	}
+
+	path := filepath.Join(t.TempDir(), "script.scream")
+	if err := Write(Path(path), source, program); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+
+	loaded, ok := Load(path, source)
+	if !ok {
+		t.Fatalf("fresh cache was not loaded")
+	}
+	if loaded.String() != program.String() {
+		t.Errorf("loaded %q, want %q", loaded.String(), program.String())
+	}
+
+	if _, err := Read(Path(path), append(source, ' ')); err != ErrStale {
+		t.Errorf("changed source gave %v, want ErrStale", err)
+	}
+
+	if err := os.WriteFile(Path(path), []byte("not a cache"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Load(path, source); ok {
+		t.Errorf("corrupt cache was loaded")
+	}
+}
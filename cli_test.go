@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"scream/bundle"
+	"scream/evaluator"
+	"scream/object"
+)
+
+// writeScript writes a file into dir, returning its path.
+func writeScript(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// runCLI calls run as the command line would, with stdin holding input,
+// returning the exit status and what the script wrote to its output and
+// error streams.
+func runCLI(t *testing.T, opts *options, args []string, input string) (int, string, string) {
+	t.Helper()
+	stdin := writeScript(t, t.TempDir(), "stdin", input)
+	f, err := os.Open(stdin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var status int
+	out, errs := capture(t, f, input, func() { status = runOnce(opts, args) })
+	return status, out, errs
+}
+
+// capture runs fn with os.Stdin set to stdin and the script streams
+// going to buffers, returning what was written to them.
+func capture(t *testing.T, stdin *os.File, input string, fn func()) (string, string) {
+	t.Helper()
+	var out, errs bytes.Buffer
+	savedStdin, savedStdio := os.Stdin, object.Stdio
+	os.Stdin = stdin
+	object.Stdio = object.NewStreams(strings.NewReader(input), &out, &errs)
+	defer func() {
+		os.Stdin, object.Stdio = savedStdin, savedStdio
+		evaluator.Reset()
+	}()
+
+	fn()
+	object.Stdio.Flush()
+	return out.String(), errs.String()
+}
+
+// TestExitFromAnyGoroutine checks that exit() ends only the current run
+// while watching, wherever it is called from.
+func TestExitFromAnyGoroutine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`PRINT("a"); exit(2); PRINT("b");`, 2},
+		{`LET t = spawn(FN() { exit(3); }); t.wait(); PRINT("unreached");`, 3},
+		{`FUNC gen() { YIELD 1; exit(4); } foreach v in gen() { PRINT(v); }`, 4},
+		{`pool(2).map(FN(x) { IF (x == 2) { exit(5); } x }, [1, 2, 3]);`, 5},
+		{`PRINT("done");`, 0},
+	}
+
+	for _, tt := range tests {
+		path := writeScript(t, t.TempDir(), "exit.scream", tt.input)
+		status, out, _ := runCLI(t, &options{}, []string{path}, "")
+		if status != tt.expected {
+			t.Errorf("got status %d, want %d for %s", status, tt.expected, tt.input)
+		}
+		if strings.Contains(out, "unreached") || strings.Contains(out, "b") {
+			t.Errorf("kept running after exit: %q for %s", out, tt.input)
+		}
+	}
+}
+
+// TestExitFromEarlierRun checks that a task left running by one run of
+// a watched script can neither end nor write to a later run, and that
+// it is gone once it calls exit().
+func TestExitFromEarlierRun(t *testing.T) {
+	held := make(chan struct{})
+	release := make(chan struct{})
+	evaluator.RegisterFunction("go_hold", func() {
+		held <- struct{}{}
+		<-release
+	})
+	evaluator.RegisterFunction("go_release", func() {
+		close(release)
+		time.Sleep(50 * time.Millisecond)
+	})
+
+	before := runtime.NumGoroutine()
+	first := writeScript(t, t.TempDir(), "first.scream",
+		`spawn(FN() { go_hold(); PRINT("stale"); exit(9); }); PRINT("first");`)
+	if status, out, _ := runCLI(t, &options{}, []string{first}, ""); status != 0 || out != "first" {
+		t.Fatalf("got %d %q from the first run", status, out)
+	}
+	<-held
+
+	second := writeScript(t, t.TempDir(), "second.scream", `go_release(); PRINT("second");`)
+	if status, out, _ := runCLI(t, &options{}, []string{second}, ""); status != 0 || out != "second" {
+		t.Errorf("got %d %q from the second run", status, out)
+	}
+
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 100 {
+			t.Fatalf("%d goroutines left running, from %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRunStatus checks the status run returns for each way a script can
+// finish.
+func TestRunStatus(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		opts     options
+		args     []string
+		expected int
+	}{
+		{options{}, []string{writeScript(t, dir, "ok.scream", `PRINT("ok");`)}, 0},
+		{options{}, []string{writeScript(t, dir, "exit.scream", `exit(7);`)}, 7},
+		{options{}, []string{writeScript(t, dir, "error.scream", `1 + "a";`)}, 1},
+		{options{}, []string{writeScript(t, dir, "loop.scream", `FOR (LET i = 0; i < 1; i++) { throw("boom"); } PRINT("after");`)}, 1},
+		{options{}, []string{filepath.Join(dir, "missing.scream")}, 1},
+		{options{eval: `PRINT("ok");`}, nil, 0},
+		{options{eval: `exit(3);`}, nil, 3},
+		{options{version: true}, nil, 0},
+	}
+
+	for _, tt := range tests {
+		opts := tt.opts
+		status, out, _ := runCLI(t, &opts, tt.args, "")
+		if status != tt.expected {
+			t.Errorf("got status %d, want %d for %+v %v", status, tt.expected, tt.opts, tt.args)
+		}
+		if strings.Contains(out, "after") {
+			t.Errorf("kept running after an uncaught error for %v", tt.args)
+		}
+	}
+}
+
+// TestRunStdin checks that "-", or no script at all, reads the script
+// from standard input.
+func TestRunStdin(t *testing.T) {
+	for _, args := range [][]string{{"-"}, nil, {"-", "--", "a"}} {
+		status, out, _ := runCLI(t, &options{}, args, `PRINT("from stdin");`)
+		if status != 0 || out != "from stdin" {
+			t.Errorf("got %d %q for %v", status, out, args)
+		}
+	}
+}
+
+// TestRunScriptArgs checks that a leading "--" is dropped from the
+// arguments the script sees.
+func TestRunScriptArgs(t *testing.T) {
+	path := writeScript(t, t.TempDir(), "args.scream", `PRINT(args());`)
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{path}, "[]"},
+		{[]string{path, "a", "b"}, "[a, b]"},
+		{[]string{path, "--", "-a", "b"}, "[-a, b]"},
+		{[]string{path, "--", "--"}, "[--]"},
+	}
+
+	for _, tt := range tests {
+		_, out, _ := runCLI(t, &options{}, tt.args, "")
+		if out != tt.expected {
+			t.Errorf("got %q, want %q for %v", out, tt.expected, tt.args)
+		}
+	}
+}
+
+// TestRunCommand checks that the run subcommand takes the same flags as
+// the top level, and passes what follows the script on to it.
+func TestRunCommand(t *testing.T) {
+	dir := t.TempDir()
+	path := writeScript(t, dir, "args.scream", `PRINT(args());`)
+	fail := writeScript(t, dir, "fail.scream", `1 + "a";`)
+	tests := []struct {
+		args     []string
+		status   int
+		expected string
+	}{
+		{[]string{"run", path, "a"}, 0, "[a]"},
+		{[]string{"run", path, "--", "-x"}, 0, "[-x]"},
+		{[]string{"run", "-eval", `PRINT(args());`, "--", "a", "b"}, 0, "[a, b]"},
+		{[]string{"-eval", `PRINT(args());`, "a"}, 0, "[a]"},
+		{[]string{"run", "-"}, 0, "[]"},
+		{[]string{"run", fail}, 1, ""},
+	}
+
+	for _, tt := range tests {
+		stdin, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var status int
+		out, _ := capture(t, stdin, "", func() { status = command(tt.args) })
+		stdin.Close()
+		if status != tt.status {
+			t.Errorf("got status %d, want %d for %v", status, tt.status, tt.args)
+		}
+		if !strings.HasPrefix(out, tt.expected) {
+			t.Errorf("got %q, want %q for %v", out, tt.expected, tt.args)
+		}
+	}
+}
+
+// TestRunBundle checks that a bundled script sees its arguments and
+// config as it would if run by the run command.
+func TestRunBundle(t *testing.T) {
+	savedPath, savedRead := evaluator.ScriptPath, evaluator.ReadImport
+	defer func() { evaluator.ScriptPath, evaluator.ReadImport = savedPath, savedRead }()
+	defer delete(evaluator.PRAGMAS, "strict")
+
+	b := &bundle.Bundle{Main: "tool/main.scream", Files: map[string][]byte{
+		"tool/main.scream":                []byte(`PRINT(args(), " ", pragma());`),
+		filepath.Join("tool", ConfigFile): []byte("pragma = strict\n"),
+	}}
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"a", "b"}, "[a, b] [strict]"},
+		{[]string{"--", "a", "b"}, "[a, b] [strict]"},
+		{[]string{"--", "--"}, "[--] [strict]"},
+	}
+
+	for _, tt := range tests {
+		var status int
+		out, _ := capture(t, os.Stdin, "", func() { status = runBundle(b, tt.args) })
+		if status != 0 || out != tt.expected {
+			t.Errorf("got status %d and %q, want %q for %v", status, out, tt.expected, tt.args)
+		}
+	}
+}
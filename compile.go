@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"scream/cache"
+	"scream/lexer"
+	"scream/parser"
+)
+
+// compile parses each of the named scripts and writes its cache file,
+// which later runs of the script use instead of parsing it again.
+func compile(paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: scream compile file.scream...\n")
+		return 2
+	}
+
+	status := 0
+	for _, path := range paths {
+		input, err := ioutil.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading: %s\n", err.Error())
+			status = 1
+			continue
+		}
+
+		p := parser.New(lexer.New(string(input)))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			fmt.Fprintf(os.Stderr, "%s:\n", path)
+			for _, msg := range p.Errors() {
+				fmt.Fprintf(os.Stderr, "\t%s\n", msg)
+			}
+			status = 1
+			continue
+		}
+
+		if err := cache.Write(cache.Path(path), input, program); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"scream/evaluator"
+)
+
+// ConfigFile is read from the directory of the script being run.
+const ConfigFile = "scream.env"
+
+// loadConfig applies the ConfigFile in dir, if there is one.  Each line
+// holds KEY=VALUE; the key "pragma" enables the comma-separated pragmas
+// given, or disables those prefixed with "no-", while any other key sets
+// an environment variable which is not already set.  Blank lines and
+// lines starting with '#' are ignored.
+func loadConfig(dir string) error {
+	path := filepath.Join(dir, ConfigFile)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return applyConfig(path, f)
+}
+
+// applyConfig applies the settings read from r, which came from path.
+func applyConfig(path string, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		eq := strings.Index(text, "=")
+		if eq < 1 {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, line)
+		}
+		key := strings.TrimSpace(text[:eq])
+		value := strings.TrimSpace(text[eq+1:])
+
+		if key != "pragma" {
+			if _, ok := os.LookupEnv(key); !ok {
+				os.Setenv(key, value)
+			}
+			continue
+		}
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if strings.HasPrefix(name, "no-") {
+				delete(evaluator.PRAGMAS, strings.TrimPrefix(name, "no-"))
+			} else if name != "" {
+				evaluator.PRAGMAS[name] = 1
+			}
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"scream/ast"
+	"scream/evaluator"
+	"scream/lexer"
+	"scream/parser"
+	"scream/token"
+)
+
+// dumpTokens prints the token stream produced by the lexer to w, one
+// token per line, either as text or as a JSON array.
+func dumpTokens(w io.Writer, input string, asJSON bool) int {
+	var tokens []token.Token
+	l := lexer.New(input)
+	for {
+		tok := l.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			break
+		}
+	}
+
+	if asJSON {
+		out, err := json.MarshalIndent(tokens, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			return 1
+		}
+		fmt.Fprintf(w, "%s\n", out)
+		return 0
+	}
+
+	for _, tok := range tokens {
+		fmt.Fprintf(w, "%d:%d\t%s\t%q\n", tok.Line, tok.Column, tok.Type, tok.Literal)
+	}
+	return 0
+}
+
+// dumpAST prints the parsed program to w as an indented tree or as JSON.
+func dumpAST(w io.Writer, input string, asJSON bool) int {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(w, "\t%s\n", msg)
+		}
+		return 1
+	}
+
+	if optimize {
+		evaluator.Optimize(program)
+	}
+
+	if asJSON {
+		out, err := ast.ToJSON(program)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			return 1
+		}
+		fmt.Fprintf(w, "%s\n", out)
+		return 0
+	}
+
+	fmt.Fprint(w, ast.ToText(program))
+	return 0
+}
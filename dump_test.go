@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite golden files with the current output.")
+
+// TestDumpJSON checks the JSON printed by -dump-tokens and -dump-ast
+// against golden files, since tools depend on its layout being stable.
+func TestDumpJSON(t *testing.T) {
+	input, err := ioutil.ReadFile(filepath.Join("testdata", "dump.scream"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		golden string
+		dump   func(*bytes.Buffer) int
+	}{
+		{"dump_tokens.json", func(out *bytes.Buffer) int { return dumpTokens(out, string(input), true) }},
+		{"dump_ast.json", func(out *bytes.Buffer) int { return dumpAST(out, string(input), true) }},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if status := tt.dump(&out); status != 0 {
+			t.Fatalf("%s: exit status %d", tt.golden, status)
+		}
+		path := filepath.Join("testdata", tt.golden)
+		if *update {
+			if err := ioutil.WriteFile(path, out.Bytes(), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(out.Bytes(), want) {
+			t.Errorf("%s differs from the golden file; got\n%s", tt.golden, out.String())
+		}
+	}
+}
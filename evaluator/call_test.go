@@ -0,0 +1,31 @@
+package evaluator
+
+import (
+	"testing"
+)
+
+func TestCallArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`FUNC f(A, B = A * 2, ...REST) { PRINT(A, B, REST, " "); }
+f(1); f(1, 5, 6, 7); f(B: 3, A: 4);`, "12[] 15[6, 7] 43[] "},
+		{`FUNC f(A, B, C) { PRINT(A, B, C); } LET xs = [1, 2]; f(...xs, 3); f(0, ...1..2);`, "123012"},
+		{`FUNC f(A, B = 2) { PRINT(A, B); } f(...{"B": 5, "A": 4});`, "45"},
+		{`LET g = FN(X, Y) { X - Y }; PRINT(g(Y: 1, X: 10));`, "9"},
+		{`FUNC object.plus(N = 1) { self + N } PRINT(2.plus(), 2.plus(N: 5));`, "37"},
+		{`PRINT(try(FN() { FN(X, Y) { X }(1) }).message());`, "missing argument Y to `FN(X, Y)`"},
+		{`FUNC f(X, Y = 2) { X } PRINT(try(f, 1, 2, 3).message());`,
+			"wrong number of arguments to `f(X, Y = 2)`. got=3, want=2"},
+		{`FUNC f(X) { X } PRINT(try(FN() { f(1, X: 2) }).message());`, "argument X given twice to `f(X)`"},
+		{`FUNC f(...R) { R } PRINT(try(FN() { f(R: 2) }).message());`, "unknown keyword argument R to `f(...R)`"},
+		{`PRINT(try(FN() { LEN("a", X: 2) }).message());`, "builtin `LEN` does not take keyword arguments"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package evaluator
+
+import (
+	"testing"
+)
+
+func TestDefer(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`FUNC f(x) {
+  DEFER PRINT("1");
+  DEFER PRINT("2", x);
+  IF (x > 1) { RETURN "early"; }
+  x = 5;
+  "late"
+}
+PRINT(f(2), f(0));`, "221251earlylate"},
+		{`FUNC f() { DEFER PRINT("closed"); LEN(1, 2); PRINT("unreached"); }
+PRINT(is_error(try(f)));`, "closedtrue"},
+		{`FUNC f() { DEFER throw("in defer"); DEFER throw("earlier"); 1 }
+PRINT(try(f).message());`, "earlier"},
+		{`FUNC f() { DEFER PRINT("ignored"); throw("first"); }
+PRINT(try(f).message());`, "ignoredfirst"},
+		{`FUNC gen() { DEFER PRINT("done"); YIELD 1; YIELD 2; }
+foreach v in gen() { PRINT(v); }
+LET g = gen(); g.next(); g.close();`, "12donedone"},
+		{`FUNC object.twice() { DEFER PRINT("after"); self * 2 } PRINT(3.twice());`, "after6"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
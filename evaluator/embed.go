@@ -0,0 +1,81 @@
+package evaluator
+
+import (
+	"fmt"
+	"reflect"
+
+	"scream/object"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterFunction makes the Go function fn callable from scripts under
+// name.  Arguments are checked against fn's parameter types and
+// converted with object.ToGoValue; a variadic final parameter accepts
+// any number of trailing arguments.  The results are converted back
+// with object.FromGo: none gives NULL, one gives its value, and several
+// give an array.  A final result of type error is not returned to the
+// script, but turns the call into an ERROR when it is non-nil.
+func RegisterFunction(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("cannot register %s: %T is not a function", name, fn)
+	}
+	t := v.Type()
+
+	results := t.NumOut()
+	failable := results > 0 && t.Out(results-1) == errorType
+	if failable {
+		results--
+	}
+
+	RegisterBuiltin(name, func(env *object.Environment, args ...object.Object) object.Object {
+		want := t.NumIn()
+		if t.IsVariadic() {
+			if len(args) < want-1 {
+				return newError("wrong number of arguments to `%s`. got=%d, want=%d+", name, len(args), want-1)
+			}
+		} else if len(args) != want {
+			return newError("wrong number of arguments to `%s`. got=%d, want=%d", name, len(args), want)
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var pt reflect.Type
+			if t.IsVariadic() && i >= want-1 {
+				pt = t.In(want - 1).Elem()
+			} else {
+				pt = t.In(i)
+			}
+			val, err := object.ToGoValue(arg, pt)
+			if err != nil {
+				return newError("argument %d to `%s`: %s", i+1, name, err)
+			}
+			in[i] = val
+		}
+
+		out := v.Call(in)
+		if failable {
+			if err := out[results]; !err.IsNil() {
+				return newError("%s", err.Interface().(error).Error())
+			}
+		}
+
+		if results == 0 {
+			return NULL
+		}
+		values := make([]object.Object, results)
+		for i := range values {
+			obj, err := object.FromGo(out[i].Interface())
+			if err != nil {
+				return newError("result of `%s`: %s", name, err)
+			}
+			values[i] = obj
+		}
+		if results == 1 {
+			return values[0]
+		}
+		return &object.Array{Elements: values}
+	})
+	return nil
+}
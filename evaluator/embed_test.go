@@ -0,0 +1,108 @@
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"scream/object"
+)
+
+func TestRegisterFunction(t *testing.T) {
+	funcs := map[string]interface{}{
+		"go_add":   func(a, b int) int { return a + b },
+		"go_half":  func(f float64) float64 { return f / 2 },
+		"go_upper": strings.ToUpper,
+		"go_not":   func(b bool) bool { return !b },
+		"go_sum": func(xs []int64) int64 {
+			var sum int64
+			for _, x := range xs {
+				sum += x
+			}
+			return sum
+		},
+		"go_keys": func(m map[string]int) []string {
+			var keys []string
+			for k := range m {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			return keys
+		},
+		"go_join": func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"go_div": func(a, b int) (int, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"go_pair": func() (string, int) { return "a", 1 },
+		"go_nop":  func() {},
+		"go_kind": func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"go_kinds": func(vs ...interface{}) string {
+			var kinds []string
+			for _, v := range vs {
+				kinds = append(kinds, fmt.Sprintf("%T", v))
+			}
+			return strings.Join(kinds, " ")
+		},
+		"go_type": func(o object.Object) string { return string(o.Type()) },
+	}
+	for name, fn := range funcs {
+		if err := RegisterFunction(name, fn); err != nil {
+			t.Fatalf("RegisterFunction(%s) failed: %s", name, err)
+		}
+	}
+	if err := RegisterFunction("go_bad", 42); err == nil {
+		t.Errorf("RegisterFunction accepted an int")
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`PRINT(go_add(2, 3));`, "5"},
+		{`PRINT(go_half(3.0), " ", go_half(3));`, "1.5 1.5"},
+		{`PRINT(go_upper("abc"));`, "ABC"},
+		{`PRINT(go_not(TRUE));`, "false"},
+		{`PRINT(go_sum([1, 2, 3]));`, "6"},
+		{`PRINT(go_keys({"b": 1, "a": 2}));`, "[a, b]"},
+		{`PRINT(go_join("-"), go_join("-", "x", "y"));`, "x-y"},
+		{`PRINT(go_div(7, 2));`, "3"},
+		{`PRINT(go_pair(), go_nop());`, "[a, 1]null"},
+		// interface{} is given plain Go values, not objects.
+		{`PRINT(go_kind(1), " ", go_kind("s"), " ", go_kind(1.5), " ", go_kind(NIL));`, "int64 string float64 <nil>"},
+		{`PRINT(go_kinds(1, "s", TRUE, [1], {"a": 1}, 1..2));`,
+			"int64 string bool []interface {} map[string]interface {} []interface {}"},
+		{`PRINT(go_type(1), go_type("s"));`, "INTEGERSTRING"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+
+	failures := []struct {
+		input    string
+		expected string
+	}{
+		{`go_div(1, 0);`, "division by zero"},
+		{`go_add(1);`, "wrong number of arguments to `go_add`. got=1, want=2"},
+		{`go_join();`, "wrong number of arguments to `go_join`. got=0, want=1+"},
+		{`go_add(1, "two");`, "argument 2 to `go_add`: "},
+		{`go_sum([1, "x"]);`, "argument 1 to `go_sum`: "},
+	}
+
+	for _, tt := range failures {
+		res, _ := evalCaptured(t, parseScript(t, tt.input))
+		err, ok := res.(*object.Error)
+		if !ok {
+			t.Errorf("got %v, want an error for %s", res, tt.input)
+		} else if !strings.HasPrefix(err.Message, tt.expected) {
+			t.Errorf("got %q, want it to start %q for %s", err.Message, tt.expected, tt.input)
+		}
+	}
+}
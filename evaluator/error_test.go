@@ -0,0 +1,46 @@
+package evaluator
+
+import (
+	"testing"
+)
+
+func TestErrorValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`LET e = error("disk full", "io"); PRINT(type(e), is_error(e), e.message(), e.kind());`,
+			"errortruedisk fullio"},
+		{`PRINT(error("x").kind(), is_error("x"), unwrap(error("x")));`, "errorfalsenull"},
+		{`LET e = error("save failed", "io", error("disk full"));
+PRINT(unwrap(e).message(), e.cause().kind());`, "disk fullerror"},
+		{`LET r = try(FN() {
+  LEN(1, 2);
+});
+LET p = r.position();
+PRINT(is_error(r), " ", p["line"], ":", p["column"]);`, "true 2:3"},
+		{`LET r = try(FN(x) { throw("bad " + x, "value"); PRINT("unreached"); }, "input");
+PRINT(r.message(), r.kind());`, "bad inputvalue"},
+		{`PRINT(try(FN(x) { x * 2 }, 21));`, "42"},
+		{`FUNC soft() { RETURN error("soft"); } LET e = soft(); PRINT("still running ", e.message());`,
+			"still running soft"},
+		{`PRINT(error("x").methods());`, "[cause, kind, message, methods, position, unwrap]"},
+		{`LET p = pool(2);
+LET r = try(FN() { p.map(FN(x) { IF (x == 2) { throw("two"); } x }, [1, 2, 3]) });
+PRINT(r.message(), " / ", unwrap(r).message());`, "map failed for 2: two / two"},
+		// Thrown errors end every enclosing loop as well.
+		{`PRINT(try(FN() { foreach x in [1, 2] { throw("boom"); } RETURN "swallowed"; }).message());`, "boom"},
+		{`LET n = 0; LET r = try(FN() { WHILE (TRUE) { n++; throw("at " + string(n)); } });
+PRINT(r.message());`, "at 1"},
+		{`LET r = try(FN() { FOR (LET i = 0; i < 3; i++) { foreach x in 1..2 { throw("inner"); } } });
+PRINT(r.message());`, "inner"},
+		{`FOR (LET i = 0; i < 2; i++) { LET e = error("held"); } PRINT("held errors do not end loops");`,
+			"held errors do not end loops"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
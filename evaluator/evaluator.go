@@ -5,10 +5,11 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 
 	"scream/ast"
 	"scream/object"
@@ -24,6 +25,16 @@ var (
 
 var builtins = map[string]*object.Builtin{}
 
+// pragmaMu guards PRAGMAS once tasks are running; setting pragmas before
+// the script starts needs no lock.
+var pragmaMu sync.RWMutex
+
+func pragmaEnabled(name string) bool {
+	pragmaMu.RLock()
+	defer pragmaMu.RUnlock()
+	return PRAGMAS[name] == 1
+}
+
 func Eval(node ast.Node, env *object.Environment) object.Object {
 	return EvalContext(context.Background(), node, env)
 }
@@ -68,21 +79,31 @@ func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) ob
 		}
 		res := evalInfixExpression(node.Operator, left, right, env)
 		if isError(res) {
-			fmt.Printf("Error: %s\n", res.Inspect())
-			if PRAGMAS["strict"] == 1 {
-				os.Exit(1)
+			reportError(env, res)
+			env.Streams().Print(fmt.Sprintf("Error: %s\n", res.Inspect()))
+			if pragmaEnabled("strict") {
+				exit(env, 1)
 			}
 		}
 		return (res)
 
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
+	case *ast.InputBlock:
+		// Only run by the line processing loop.
+		return NULL
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 	case *ast.TernaryExpression:
 		return evalTernaryExpression(node, env)
 	case *ast.ForLoopExpression:
 		return evalForLoopExpression(node, env)
+	case *ast.CountedForExpression:
+		return evalCountedForExpression(node, env)
+	case *ast.BreakStatement:
+		return &object.LoopControl{}
+	case *ast.ContinueStatement:
+		return &object.LoopControl{Continue: true}
 	case *ast.ForeachStatement:
 		return evalForeachExpression(node, env)
 	case *ast.ReturnStatement:
@@ -91,39 +112,67 @@ func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) ob
 			return val
 		}
 		return &object.ReturnValue{Value: val}
+	case *ast.YieldStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		yield := env.Yield()
+		if yield == nil {
+			return newError("YIELD outside of a generator")
+		}
+		yield(val)
+		return NULL
+	case *ast.DeferStatement:
+		defers := env.Defers()
+		if defers == nil {
+			return newError("DEFER outside of a function")
+		}
+		defers.Add(func() object.Object {
+			return Eval(node.Call, env)
+		})
+		return NULL
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+		return env.Set(node.Name.Value, val)
+	case *ast.DestructuringStatement:
+		val := Eval(node.Value, env)
+		if isError(val) {
+			return val
+		}
+		if err := bindPattern(node.Pattern, val, env); err != nil {
+			return err
+		}
 		return val
 	case *ast.ConstStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.SetConst(node.Name.Value, val)
-		return val
+		return env.SetConst(node.Name.Value, val)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
 		defaults := node.Defaults
-		return &object.Function{Parameters: params, Env: env, Body: body, Defaults: defaults}
+		return &object.Function{Parameters: params, Env: env, Body: body, Defaults: defaults, Patterns: node.Patterns, Generator: node.Generator, Variadic: node.Variadic}
 	case *ast.FunctionDefineLiteral:
 		params := node.Parameters
 		body := node.Body
 		defaults := node.Defaults
-		env.Set(node.TokenLiteral(), &object.Function{Parameters: params, Env: env, Body: body, Defaults: defaults})
+		env.Set(node.TokenLiteral(), &object.Function{Name: node.TokenLiteral(), Parameters: params, Env: env, Body: body, Defaults: defaults, Patterns: node.Patterns, Generator: node.Generator, Variadic: node.Variadic})
 		return NULL
 	case *ast.ObjectCallExpression:
 		res := evalObjectCallExpression(node, env)
 		if isError(res) {
-			fmt.Fprintf(os.Stderr, "Error calling object-method %s\n", res.Inspect())
-			if PRAGMAS["strict"] == 1 {
-				os.Exit(1)
+			reportError(env, res)
+			env.Streams().Diagnose(fmt.Sprintf("Error calling object-method %s\n", res.Inspect()))
+			if pragmaEnabled("strict") {
+				exit(env, 1)
 			}
 		}
 		return res
@@ -132,20 +181,33 @@ func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) ob
 		if isError(function) {
 			return function
 		}
-		args := evalExpression(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
+		args, kwargs, err := evalArguments(node.Arguments, env)
+		if err != nil {
+			return err
+		}
+		traced := traceEnabled()
+		frames := env.Frames()
+		if traced {
+			frames = TRACE.call(env, node.Function.String(), args)
+		}
+		res := callFunction(env, frames, function, args, kwargs)
+		if traced {
+			TRACE.ret(frames, node.Function.String(), res)
 		}
-		res := applyFunction(env, function, args)
 		if isError(res) {
-			fmt.Fprintf(os.Stderr, "Error calling `%s` : %s\n", node.Function, res.Inspect())
-			if PRAGMAS["strict"] == 1 {
-				os.Exit(1)
+			reportError(env, res)
+			env.Streams().Diagnose(fmt.Sprintf("Error calling `%s` : %s\n", node.Function, res.Inspect()))
+			if pragmaEnabled("strict") {
+				exit(env, 1)
 			}
 			return res
 		}
 		return res
 
+	case *ast.SpreadExpression:
+		return newError("%s outside of the arguments of a call", node.String())
+	case *ast.KeywordArgument:
+		return newError("keyword argument %s outside of the arguments of a call", node.Name)
 	case *ast.ArrayLiteral:
 		elements := evalExpression(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
@@ -157,7 +219,7 @@ func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) ob
 	case *ast.RegexpLiteral:
 		return &object.Regexp{Value: node.Value, Flags: node.Flags}
 	case *ast.BacktickLiteral:
-		return backTickOperation(node.Value)
+		return backTickOperation(env, node.Value)
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
@@ -181,10 +243,17 @@ func EvalContext(ctx context.Context, node ast.Node, env *object.Environment) ob
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 	for _, statement := range block.Statements {
+		if traceEnabled() {
+			TRACE.statement(env, statement)
+		}
 		result = Eval(statement, env)
+		if isError(result) {
+			reportError(env, result)
+			return locateError(result.(*object.Error), statement)
+		}
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.LOOP_CONTROL_OBJ {
 				return result
 			}
 		}
@@ -272,6 +341,8 @@ func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 
 func evalInfixExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
 	switch {
+	case operator == "step":
+		return evalStepExpression(left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
 	case left.Type() == object.FLOAT_OBJ && right.Type() == object.FLOAT_OBJ:
@@ -307,6 +378,21 @@ func evalInfixExpression(operator string, left, right object.Object, env *object
 	}
 }
 
+func evalStepExpression(left, right object.Object) object.Object {
+	r, ok := left.(*object.Range)
+	if !ok {
+		return newError("step must follow a RANGE, got %s", left.Type())
+	}
+	step, ok := right.(*object.Integer)
+	if !ok {
+		return newError("range step must be INTEGER, got %s", right.Type())
+	}
+	if step.Value <= 0 {
+		return newError("range step must be positive, got %d", step.Value)
+	}
+	return &object.Range{Start: r.Start, End: r.End, Step: step.Value}
+}
+
 func matches(left, right object.Object, env *object.Environment) object.Object {
 
 	str := left.Inspect()
@@ -422,15 +508,7 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 	case "..":
-		len := int(rightVal-leftVal) + 1
-		array := make([]object.Object, len)
-		i := 0
-		for i < len {
-			array[i] = &object.Integer{Value: leftVal}
-			leftVal++
-			i++
-		}
-		return &object.Array{Elements: array}
+		return &object.Range{Start: leftVal, End: rightVal, Step: 1}
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
@@ -632,12 +710,11 @@ func evalAssignStatement(a *ast.AssignStatement, env *object.Environment) (val o
 
 		res := evalInfixExpression("+=", current, evaluated, env)
 		if isError(res) {
-			fmt.Printf("Error handling += %s\n", res.Inspect())
+			env.Streams().Print(fmt.Sprintf("Error handling += %s\n", res.Inspect()))
 			return res
 		}
 
-		env.Set(a.Name.String(), res)
-		return res
+		return env.Set(a.Name.String(), res)
 
 	case "-=":
 
@@ -648,12 +725,11 @@ func evalAssignStatement(a *ast.AssignStatement, env *object.Environment) (val o
 
 		res := evalInfixExpression("-=", current, evaluated, env)
 		if isError(res) {
-			fmt.Printf("Error handling -= %s\n", res.Inspect())
+			env.Streams().Print(fmt.Sprintf("Error handling -= %s\n", res.Inspect()))
 			return res
 		}
 
-		env.Set(a.Name.String(), res)
-		return res
+		return env.Set(a.Name.String(), res)
 
 	case "*=":
 		current, ok := env.Get(a.Name.String())
@@ -663,12 +739,11 @@ func evalAssignStatement(a *ast.AssignStatement, env *object.Environment) (val o
 
 		res := evalInfixExpression("*=", current, evaluated, env)
 		if isError(res) {
-			fmt.Printf("Error handling *= %s\n", res.Inspect())
+			env.Streams().Print(fmt.Sprintf("Error handling *= %s\n", res.Inspect()))
 			return res
 		}
 
-		env.Set(a.Name.String(), res)
-		return res
+		return env.Set(a.Name.String(), res)
 
 	case "/=":
 
@@ -679,23 +754,22 @@ func evalAssignStatement(a *ast.AssignStatement, env *object.Environment) (val o
 
 		res := evalInfixExpression("/=", current, evaluated, env)
 		if isError(res) {
-			fmt.Printf("Error handling /= %s\n", res.Inspect())
+			env.Streams().Print(fmt.Sprintf("Error handling /= %s\n", res.Inspect()))
 			return res
 		}
 
-		env.Set(a.Name.String(), res)
-		return res
+		return env.Set(a.Name.String(), res)
 
 	case "=":
-		if PRAGMAS["strict"] == 1 {
+		if pragmaEnabled("strict") {
 			_, ok := env.Get(a.Name.String())
 			if !ok {
-				fmt.Printf("Setting unknown variable '%s' is a bug under strict-pragma!\n", a.Name.String())
-				os.Exit(1)
+				env.Streams().Print(fmt.Sprintf("Setting unknown variable '%s' is a bug under strict-pragma!\n", a.Name.String()))
+				exit(env, 1)
 			}
 		}
 
-		env.Set(a.Name.String(), evaluated)
+		return env.Set(a.Name.String(), evaluated)
 	}
 	return evaluated
 }
@@ -703,6 +777,9 @@ func evalAssignStatement(a *ast.AssignStatement, env *object.Environment) (val o
 func evalSwitchStatement(se *ast.SwitchExpression, env *object.Environment) object.Object {
 
 	obj := Eval(se.Value, env)
+	if isError(obj) {
+		return obj
+	}
 
 	for _, opt := range se.Choices {
 
@@ -712,26 +789,32 @@ func evalSwitchStatement(se *ast.SwitchExpression, env *object.Environment) obje
 
 		for _, val := range opt.Expr {
 
-			out := Eval(val, env)
-
-			// Is it a literal match?
-			if obj.Type() == out.Type() &&
-				(obj.Inspect() == out.Inspect()) {
-
-				blockOut := evalBlockStatement(opt.Block, env)
-				return blockOut
+			// Each attempt binds its names afresh, so those of a
+			// failed match never leak into the next.  A temporary
+			// scope permitting nothing would keep every assignment
+			// in the block to itself.
+			scope := env
+			if names := caseNames(opt); names != nil {
+				scope = object.NewTemporaryScope(env, names)
+			}
+			matched, err := matchCase(val, obj, scope)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				continue
 			}
 
-			if out.Type() == object.REGEXP_OBJ {
-
-				m := matches(obj, out, env)
-				if m == TRUE {
-
-					out := evalBlockStatement(opt.Block, env)
-					return out
-
+			if opt.Guard != nil {
+				guard := Eval(opt.Guard, scope)
+				if isError(guard) {
+					return guard
+				}
+				if !isTruthy(guard) {
+					continue
 				}
 			}
+			return switchValue(evalBlockStatement(opt.Block, scope))
 		}
 	}
 
@@ -741,11 +824,76 @@ func evalSwitchStatement(se *ast.SwitchExpression, env *object.Environment) obje
 		if opt.Default {
 
 			out := evalBlockStatement(opt.Block, env)
-			return out
+			return switchValue(out)
 		}
 	}
 
-	return nil
+	return NULL
+}
+
+// matchCase reports whether obj matches one value of a case, binding
+// any names a pattern holds within scope.  Values match if they are the
+// same, if they are a regexp matching obj, or a range holding it.
+func matchCase(val ast.Expression, obj object.Object, scope *object.Environment) (bool, object.Object) {
+	switch val := val.(type) {
+	case *ast.TypePattern:
+		return hasType(obj, val.Name), nil
+	case *ast.VariablePattern:
+		return destructure(val.Name, obj, scope, true)
+	case *ast.ArrayPattern, *ast.HashPattern:
+		return matchPattern(val, obj, scope)
+	}
+
+	out := Eval(val, scope)
+	if isError(out) {
+		return false, out
+	}
+
+	// Is it a literal match?
+	if sameValue(obj, out) {
+		return true, nil
+	}
+
+	switch out := out.(type) {
+	case *object.Regexp:
+		return matches(obj, out, scope) == TRUE, nil
+	case *object.Range:
+		if i, ok := obj.(*object.Integer); ok {
+			return out.Contains(i.Value), nil
+		}
+	}
+	return false, nil
+}
+
+// hasType reports whether obj has the type named, as given by type().
+func hasType(obj object.Object, name string) bool {
+	if obj.Type() == object.NULL_OBJ {
+		return name == "null"
+	}
+	t, ok := typeFun(obj).(*object.String)
+	return ok && t.Value == name
+}
+
+// switchValue is the value of a switch whose chosen block evaluated to
+// res, which is NULL for an empty block.
+func switchValue(res object.Object) object.Object {
+	if res == nil {
+		return NULL
+	}
+	return res
+}
+
+// caseNames lists the names the values of a case bind, which live in a
+// temporary scope of their own.
+func caseNames(ce *ast.CaseExpression) []string {
+	var names []string
+	for _, e := range ce.Expr {
+		switch e.(type) {
+		case *ast.ArrayPattern, *ast.HashPattern, *ast.VariablePattern:
+			names = append(names, patternNames(e)...)
+		}
+	}
+	return names
 }
 
 func evalForLoopExpression(fle *ast.ForLoopExpression, env *object.Environment) object.Object {
@@ -756,9 +904,11 @@ func evalForLoopExpression(fle *ast.ForLoopExpression, env *object.Environment)
 			return condition
 		}
 		if isTruthy(condition) {
-			rt := Eval(fle.Consequence, env)
-			if !isError(rt) && (rt.Type() == object.RETURN_VALUE_OBJ || rt.Type() == object.ERROR_OBJ) {
-				return rt
+			if rt, done := evalLoopBody(fle.Consequence, env); done {
+				if rt != nil {
+					return rt
+				}
+				break
 			}
 		} else {
 			break
@@ -767,20 +917,71 @@ func evalForLoopExpression(fle *ast.ForLoopExpression, env *object.Environment)
 	return rt
 }
 
+func evalCountedForExpression(cfe *ast.CountedForExpression, env *object.Environment) object.Object {
+	// A temporary scope permitting nothing would keep every assignment
+	// in the body to itself.
+	child := env
+	if let, ok := cfe.Init.(*ast.LetStatement); ok {
+		child = object.NewTemporaryScope(env, []string{let.Name.Value})
+	}
+
+	if cfe.Init != nil {
+		if init := Eval(cfe.Init, child); isError(init) {
+			return init
+		}
+	}
+	for {
+		if cfe.Condition != nil {
+			condition := Eval(cfe.Condition, child)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				break
+			}
+		}
+		if rt, done := evalLoopBody(cfe.Body, child); done {
+			if rt != nil {
+				return rt
+			}
+			break
+		}
+		if cfe.Post != nil {
+			if post := Eval(cfe.Post, child); isError(post) {
+				return post
+			}
+		}
+	}
+	return NULL
+}
+
+// evalLoopBody runs one iteration of a loop, reporting whether the loop
+// is done.  The result is the RETURN or thrown error a finished loop
+// must pass on, or nil if it was ended by BREAK.
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	switch rt := Eval(body, env).(type) {
+	case *object.ReturnValue:
+		return rt, true
+	case *object.LoopControl:
+		return nil, !rt.Continue
+	case *object.Error:
+		if isError(rt) {
+			return rt, true
+		}
+	}
+	return nil, false
+}
+
 func evalForeachExpression(fle *ast.ForeachStatement, env *object.Environment) object.Object {
 
 	val := Eval(fle.Value, env)
 
-	helper, ok := val.(object.Iterable)
+	helper, ok := object.AsFreshIterable(val)
 	if !ok {
 		return newError("%s object doesn't implement the Iterable interface", val.Type())
 	}
 
-	var permit []string
-	permit = append(permit, fle.Ident)
-	if fle.Index != "" {
-		permit = append(permit, fle.Index)
-	}
+	permit := foreachNames(fle)
 
 	child := object.NewTemporaryScope(env, permit)
 
@@ -790,21 +991,32 @@ func evalForeachExpression(fle *ast.ForeachStatement, env *object.Environment) o
 
 	for ok {
 
-		child.Set(fle.Ident, ret)
+		if fle.Pattern != nil {
+			if err := bindPattern(fle.Pattern, ret, child); err != nil {
+				return err
+			}
+		} else {
+			child.Set(fle.Ident, ret)
+		}
 
 		idxName := fle.Index
 		if idxName != "" {
 			child.Set(fle.Index, idx)
 		}
 
-		rt := Eval(fle.Body, child)
-
-		if !isError(rt) && (rt.Type() == object.RETURN_VALUE_OBJ || rt.Type() == object.ERROR_OBJ) {
-			return rt
+		if rt, done := evalLoopBody(fle.Body, child); done {
+			closeIterable(helper)
+			if rt != nil {
+				return rt
+			}
+			break
 		}
 		ret, idx, ok = helper.Next()
 	}
 
+	if err := iterationError(helper); err != nil {
+		return err
+	}
 	return &object.Null{}
 }
 
@@ -824,40 +1036,85 @@ func isTruthy(obj object.Object) bool {
 func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 	for _, statement := range program.Statements {
+		if traceEnabled() {
+			TRACE.statement(env, statement)
+		}
 		result = Eval(statement, env)
+		if isError(result) {
+			reportError(env, result)
+			return locateError(result.(*object.Error), statement)
+		}
 		switch result := result.(type) {
 		case *object.ReturnValue:
 			return result.Value
-		case *object.Error:
-			return result
+		case *object.LoopControl:
+			return newError("%s outside of a loop", result.Inspect())
 		}
 	}
 	return result
 }
 
+// locateError records that err was raised by stmt, unless it already
+// knows where it was raised.
+func locateError(err *object.Error, stmt ast.Statement) *object.Error {
+	if err.Line == 0 {
+		tok := ast.TokenOf(stmt)
+		err.Line, err.Column = tok.Line, tok.Column
+	}
+	return err
+}
+
+// reportError tells the hooks about an error, unless they have already
+// been told.  newError has no environment, and so no hooks, to tell;
+// instead errors are reported by the first call they are returned from
+// or statement they end, whichever comes first.
+func reportError(env *object.Environment, err object.Object) {
+	e := err.(*object.Error)
+	if e.Reported {
+		return
+	}
+	e.Reported = true
+	for _, h := range env.Hooks() {
+		h.OnError(e)
+	}
+}
+
+// exit flushes any buffered output and terminates the interpreter.
+func exit(env *object.Environment, code int) {
+	env.Streams().Flush()
+	env.Exit(code)
+}
+
 func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// isError reports whether obj is a thrown error, rather than one held
+// as an ordinary value.
 func isError(obj object.Object) bool {
-	if obj != nil {
-		return obj.Type() == object.ERROR_OBJ
-	}
-	return false
+	err, ok := obj.(*object.Error)
+	return ok && !err.Held
 }
 
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if node.Resolved {
+		if val, ok := env.GetAt(node.Depth, node.Value); ok {
+			return val
+		}
+	}
 	if val, ok := env.Get(node.Value); ok {
 		return val
 	}
 	if builtin, ok := builtins[node.Value]; ok {
 		return builtin
 	}
-	fmt.Fprintf(os.Stderr, "identifier not found: %s\n", node.Value)
-	if PRAGMAS["strict"] == 1 {
-		os.Exit(1)
+	env.Streams().Diagnose(fmt.Sprintf("identifier not found: %s\n", node.Value))
+	if pragmaEnabled("strict") {
+		exit(env, 1)
 	}
-	return newError("identifier not found: " + node.Value)
+	err := newError("identifier not found: " + node.Value)
+	reportError(env, err)
+	return err
 }
 
 func evalExpression(exps []ast.Expression, env *object.Environment) []object.Object {
@@ -872,6 +1129,74 @@ func evalExpression(exps []ast.Expression, env *object.Environment) []object.Obj
 	return result
 }
 
+// evalArguments evaluates the arguments of a call, expanding any spread
+// among them, and separating those given by keyword.
+func evalArguments(exps []ast.Expression, env *object.Environment) ([]object.Object, map[string]object.Object, object.Object) {
+	var args []object.Object
+	var kwargs map[string]object.Object
+	keyword := func(name string, val object.Object) object.Object {
+		if kwargs == nil {
+			kwargs = make(map[string]object.Object)
+		}
+		if _, ok := kwargs[name]; ok {
+			return newError("keyword argument %s given twice", name)
+		}
+		kwargs[name] = val
+		return nil
+	}
+
+	for _, e := range exps {
+		switch e := e.(type) {
+		case *ast.KeywordArgument:
+			val := Eval(e.Value, env)
+			if isError(val) {
+				return nil, nil, val
+			}
+			if err := keyword(e.Name, val); err != nil {
+				return nil, nil, err
+			}
+		case *ast.SpreadExpression:
+			val := Eval(e.Value, env)
+			if isError(val) {
+				return nil, nil, val
+			}
+			switch val := val.(type) {
+			case *object.Array:
+				args = append(args, val.Elements...)
+			case *object.Hash:
+				for _, pair := range val.Pairs {
+					name, ok := pair.Key.(*object.String)
+					if !ok {
+						return nil, nil, newError("keyword spread from a hash needs STRING keys, got=%s", pair.Key.Type())
+					}
+					if err := keyword(name.Value, pair.Value); err != nil {
+						return nil, nil, err
+					}
+				}
+			default:
+				it, ok := object.AsFreshIterable(val)
+				if !ok {
+					return nil, nil, newError("cannot spread %s", val.Type())
+				}
+				it.Reset()
+				for v, _, ok := it.Next(); ok; v, _, ok = it.Next() {
+					args = append(args, v)
+				}
+				if err := iterationError(it); err != nil {
+					return nil, nil, err
+				}
+			}
+		default:
+			val := Eval(e, env)
+			if isError(val) {
+				return nil, nil, val
+			}
+			args = append(args, val)
+		}
+	}
+	return args, kwargs, nil
+}
+
 func splitCommand(input string) []string {
 	r := regexp.MustCompile(`[^\s"']+|"([^"]*)"|'([^']*)`)
 	res := r.FindAllString(input, -1)
@@ -891,7 +1216,7 @@ func trimQuotes(in string, c byte) string {
 	return in
 }
 
-func backTickOperation(command string) object.Object {
+func backTickOperation(env *object.Environment, command string) object.Object {
 
 	toExec := splitCommand(command)
 	cmd := exec.Command(toExec[0], toExec[1:]...)
@@ -902,7 +1227,7 @@ func backTickOperation(command string) object.Object {
 	err := cmd.Run()
 
 	if err != nil && err != err.(*exec.ExitError) {
-		fmt.Printf("Failed to run '%s' -> %s\n", command, err.Error())
+		env.Streams().Print(fmt.Sprintf("Failed to run '%s' -> %s\n", command, err.Error()))
 		return NULL
 	}
 
@@ -925,10 +1250,14 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.RANGE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalRangeIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	case left.Type() == object.STRING_OBJ:
 		return evalStringIndexExpression(left, index)
+	case isHostObject(left):
+		return evalHostIndexExpression(left, index)
 	default:
 		return newError("index operator not support:%s", left.Type())
 
@@ -944,9 +1273,18 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	}
 	return arrayObject.Elements[idx]
 }
+func evalRangeIndexExpression(r, index object.Object) object.Object {
+	rangeObject := r.(*object.Range)
+	idx := index.(*object.Integer).Value
+	if idx < 0 || idx >= rangeObject.Len() {
+		return NULL
+	}
+	return &object.Integer{Value: rangeObject.At(idx)}
+}
+
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
+	key, ok := object.AsHashable(index)
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
@@ -972,6 +1310,22 @@ func evalStringIndexExpression(input, index object.Object) object.Object {
 	return &object.String{Value: string(ret)}
 }
 
+func isHostObject(obj object.Object) bool {
+	_, ok := obj.(*object.HostObject)
+	return ok
+}
+
+func evalHostIndexExpression(host, index object.Object) object.Object {
+	name, ok := index.(*object.String)
+	if !ok {
+		return newError("index operator not support:%s[%s]", host.Type(), index.Type())
+	}
+	if prop := host.(*object.HostObject).Property(name.Value); prop != nil {
+		return prop
+	}
+	return NULL
+}
+
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
 	pairs := make(map[object.HashKey]object.HashPair)
 	for keyNode, valueNode := range node.Pairs {
@@ -979,7 +1333,7 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 		if isError(key) {
 			return key
 		}
-		hashKey, ok := key.(object.Hashable)
+		hashKey, ok := object.AsHashable(key)
 		if !ok {
 			return newError("unusable as hash key: %s", key.Type())
 		}
@@ -995,42 +1349,177 @@ func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Obje
 
 }
 
-func applyFunction(env *object.Environment, fn object.Object, args []object.Object) object.Object {
+// Call calls the function or builtin fn with the given arguments, as a
+// script would.
+func Call(env *object.Environment, fn object.Object, args ...object.Object) object.Object {
+	return applyFunction(env, fn, args, nil)
+}
+
+// applyFunction calls fn with the given arguments, and any given by
+// keyword, which only functions defined by scripts accept.
+func applyFunction(env *object.Environment, fn object.Object, args []object.Object, kwargs map[string]object.Object) object.Object {
+	return callFunction(env, env.Frames(), fn, args, kwargs)
+}
+
+// callFunction calls fn from env, its body being reached by the calls
+// listed in frames.
+func callFunction(env *object.Environment, frames []string, fn object.Object, args []object.Object, kwargs map[string]object.Object) object.Object {
+	var name string
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendEnv)
-		return upwrapReturnValue(evaluated)
+		name = fn.Name
+		if name == "" {
+			name = "FN"
+		}
 	case *object.Builtin:
-		return fn.Fn(env, args...)
+		name = fn.Name
 	default:
 		return newError("not a function: %s", fn.Type())
 	}
 
+	hooks := env.Hooks()
+	for _, h := range hooks {
+		if err := h.BeforeCall(name, args); err != nil {
+			return newError("%s", err.Error())
+		}
+	}
+
+	var res object.Object
+	switch fn := fn.(type) {
+	case *object.Function:
+		if extendEnv, err := extendFunctionEnv(frames, fn, args, kwargs); err != nil {
+			res = err
+		} else {
+			res = evalFunctionBody(name, fn, extendEnv)
+		}
+	case *object.Builtin:
+		if len(kwargs) > 0 {
+			res = newError("builtin `%s` does not take keyword arguments", name)
+		} else {
+			res = fn.Fn(env, args...)
+		}
+	}
+
+	if isError(res) {
+		reportError(env, res)
+	}
+	for _, h := range hooks {
+		h.AfterCall(name, args, res)
+	}
+	return res
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// extendFunctionEnv binds the arguments of a call to fn's parameters in
+// a new scope, reached by the calls listed in frames.  Parameters are
+// bound in order, so that a default may refer to those before it.
+func extendFunctionEnv(frames []string, fn *object.Function, args []object.Object, kwargs map[string]object.Object) (*object.Environment, object.Object) {
+	params := fn.Parameters
+	var rest *ast.Identifier
+	if fn.Variadic {
+		rest = params[len(params)-1]
+		params = params[:len(params)-1]
+	}
+	if rest == nil && len(args) > len(params) {
+		return nil, newError("wrong number of arguments to `%s`. got=%d, want=%d",
+			fn.Signature(), len(args), len(params))
+	}
+
+	names := make([]string, 0, len(kwargs))
+	for name := range kwargs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		found := false
+		for i, param := range params {
+			if param.Value != name {
+				continue
+			}
+			if i < len(args) {
+				return nil, newError("argument %s given twice to `%s`", name, fn.Signature())
+			}
+			found = true
+		}
+		if !found {
+			return nil, newError("unknown keyword argument %s to `%s`", name, fn.Signature())
+		}
+	}
+
 	env := object.NewEnclosedEnvironment(fn.Env)
-	for key, val := range fn.Defaults {
-		env.Set(key, Eval(val, env))
+	if frames == nil {
+		frames = []string{}
+	}
+	env.SetFrames(frames)
+	for i, param := range params {
+		val, ok := kwargs[param.Value]
+		if i < len(args) {
+			val = args[i]
+		} else if !ok {
+			def, ok := fn.Defaults[param.Value]
+			if !ok {
+				return nil, newError("missing argument %s to `%s`", param.Value, fn.Signature())
+			}
+			val = Eval(def, env)
+			if isError(val) {
+				return nil, val
+			}
+		}
+		if res := env.Set(param.Value, val); isError(res) {
+			return nil, res
+		}
+		if pattern, ok := fn.Patterns[param.Value]; ok {
+			if err := bindPattern(pattern, val, env); err != nil {
+				return nil, object.Wrap(err.(*object.Error), "bad argument to `%s`", fn.Signature())
+			}
+		}
+	}
+	if rest != nil {
+		var extra []object.Object
+		if len(args) > len(params) {
+			extra = append(extra, args[len(params):]...)
+		}
+		if res := env.Set(rest.Value, &object.Array{Elements: extra}); isError(res) {
+			return nil, res
+		}
 	}
-	for paramIdx, param := range fn.Parameters {
-		if paramIdx < len(args) {
-			env.Set(param.Value, args[paramIdx])
+	return env, nil
+}
+
+// evalFunctionBody calls fn, whose arguments env already holds.  The
+// code it defers runs once the body has been evaluated, however it
+// finished; for a generator, once it has produced its last value.
+func evalFunctionBody(name string, fn *object.Function, env *object.Environment) object.Object {
+	if fn.Generator {
+		return newGenerator(name, fn.Body, env)
+	}
+	defers := &object.Defers{}
+	env.SetDefers(defers)
+	return runDefers(defers, upwrapReturnValue(Eval(fn.Body, env)))
+}
+
+// runDefers runs deferred code, returning the result of the call which
+// deferred it, unless that succeeded while the deferred code failed.
+func runDefers(defers *object.Defers, res object.Object) object.Object {
+	for _, val := range defers.Run() {
+		if isError(val) && !isError(res) {
+			res = val
 		}
 	}
-	return env
+	return res
 }
 
 func upwrapReturnValue(obj object.Object) object.Object {
-	if returnValue, ok := obj.(*object.ReturnValue); ok {
-		return returnValue.Value
+	switch obj := obj.(type) {
+	case *object.ReturnValue:
+		return obj.Value
+	case *object.LoopControl:
+		return newError("%s outside of a loop", obj.Inspect())
 	}
 	return obj
 }
 
 func RegisterBuiltin(name string, fun object.BuiltinFunction) {
-	builtins[name] = &object.Builtin{Fn: fun}
+	builtins[name] = &object.Builtin{Name: name, Fn: fun}
 }
 
 func SetContext(ctx context.Context) {
@@ -1040,16 +1529,30 @@ func SetContext(ctx context.Context) {
 func evalObjectCallExpression(call *ast.ObjectCallExpression, env *object.Environment) object.Object {
 
 	obj := Eval(call.Object, env)
+	if isError(obj) {
+		return obj
+	}
 	if method, ok := call.Call.(*ast.CallExpression); ok {
 
-		args := evalExpression(call.Call.(*ast.CallExpression).Arguments, env)
-		ret := obj.InvokeMethod(method.Function.String(), *env, args...)
-		if ret != nil {
-			return ret
+		args, kwargs, err := evalArguments(method.Arguments, env)
+		if err != nil {
+			return err
+		}
+		// Built-in methods take no keyword arguments.
+		if len(kwargs) == 0 {
+			ret := obj.InvokeMethod(method.Function.String(), *env, args...)
+			if ret != nil {
+				return ret
+			}
 		}
 
 		attempts := []string{}
 		attempts = append(attempts, strings.ToLower(string(obj.Type())))
+		// A range is an array whose elements are made on demand, so it
+		// has the methods defined for arrays too.
+		if obj.Type() == object.RANGE_OBJ {
+			attempts = append(attempts, "array")
+		}
 		attempts = append(attempts, "object")
 
 		for _, prefix := range attempts {
@@ -1057,13 +1560,20 @@ func evalObjectCallExpression(call *ast.ObjectCallExpression, env *object.Enviro
 
 			if fn, ok := env.Get(name); ok {
 
-				extendEnv := extendFunctionEnv(fn.(*object.Function), args)
-
-				extendEnv.Set("self", obj)
-
-				evaluated := Eval(fn.(*object.Function).Body, extendEnv)
-				obj = upwrapReturnValue(evaluated)
-				return obj
+				traced := traceEnabled()
+				frames := env.Frames()
+				if traced {
+					frames = TRACE.call(env, name, args)
+				}
+				self := obj
+				if r, ok := obj.(*object.Range); ok && prefix == "array" {
+					self = r.ToArray()
+				}
+				res := callMethod(env, frames, name, fn.(*object.Function), self, args, kwargs)
+				if traced {
+					TRACE.ret(frames, name, res)
+				}
+				return res
 			}
 		}
 
@@ -1072,6 +1582,30 @@ func evalObjectCallExpression(call *ast.ObjectCallExpression, env *object.Enviro
 	return newError("Failed to invoke method: %s", call.Call.(*ast.CallExpression).Function.String())
 }
 
+// callMethod calls fn, defined in the script as the method name, on obj.
+func callMethod(env *object.Environment, frames []string, name string, fn *object.Function, obj object.Object, args []object.Object, kwargs map[string]object.Object) object.Object {
+	extendEnv, err := extendFunctionEnv(frames, fn, args, kwargs)
+	if err != nil {
+		return err
+	}
+
+	if res := extendEnv.Set("self", obj); isError(res) {
+		return res
+	}
+
+	hooks := env.Hooks()
+	for _, h := range hooks {
+		if err := h.BeforeCall(name, args); err != nil {
+			return newError("%s", err.Error())
+		}
+	}
+	res := evalFunctionBody(name, fn, extendEnv)
+	for _, h := range hooks {
+		h.AfterCall(name, args, res)
+	}
+	return res
+}
+
 func objectToNativeBoolean(o object.Object) bool {
 	if r, ok := o.(*object.ReturnValue); ok {
 		o = r.Value
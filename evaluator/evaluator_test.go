@@ -0,0 +1,53 @@
+package evaluator
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"scream/ast"
+	"scream/lexer"
+	"scream/object"
+	"scream/parser"
+)
+
+// runScript evaluates input in a fresh environment, returning what it
+// printed.
+func runScript(t *testing.T, input string) string {
+	t.Helper()
+	return runProgram(t, parseScript(t, input))
+}
+
+// parseScript parses input, failing the test if it is not valid.
+func parseScript(t *testing.T, input string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parse errors: %s", strings.Join(p.Errors(), "; "))
+	}
+	return program
+}
+
+// runProgram evaluates program, returning what it printed.
+func runProgram(t *testing.T, program *ast.Program) string {
+	t.Helper()
+	res, printed := evalCaptured(t, program)
+	if isError(res) {
+		t.Fatalf("evaluation failed: %s", res.Inspect())
+	}
+	return printed
+}
+
+// evalCaptured evaluates program in a fresh environment, returning its
+// result and what it printed, without any diagnostics.
+func evalCaptured(t *testing.T, program *ast.Program) (object.Object, string) {
+	t.Helper()
+	var out bytes.Buffer
+	env := object.NewEnvironment()
+	env.SetStreams(object.NewStreams(strings.NewReader(""), &out, io.Discard))
+	res := Eval(program, env)
+	env.Streams().Flush()
+	return res, out.String()
+}
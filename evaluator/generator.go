@@ -0,0 +1,105 @@
+package evaluator
+
+import (
+	"scream/ast"
+	"scream/object"
+)
+
+// newGenerator returns the generator produced by calling a function
+// whose body contains YIELD, with its arguments already bound in env.
+// Code the body defers runs when it finishes or the generator is closed.
+func newGenerator(name string, body *ast.BlockStatement, env *object.Environment) *object.Generator {
+	return object.NewGenerator(name, func(yield func(object.Object)) (res object.Object) {
+		defers := &object.Defers{}
+		env.SetYield(yield)
+		env.SetDefers(defers)
+		defer func() {
+			env.SetYield(nil)
+			res = runDefers(defers, res)
+		}()
+		return upwrapReturnValue(Eval(body, env))
+	})
+}
+
+// iterationError returns the error which ended a generator, if it is
+// one.
+func iterationError(it object.Iterable) object.Object {
+	if g, ok := it.(*object.Generator); ok && g.Err() != nil {
+		return g.Err()
+	}
+	return nil
+}
+
+// closeIterable closes it if it is a generator, since nothing else
+// will finish one left part way through.
+func closeIterable(it object.Iterable) {
+	if g, ok := it.(*object.Generator); ok {
+		g.Close()
+	}
+}
+
+func takeFun(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("wrong number of arguments. got=%d, want=2",
+			len(args))
+	}
+	it, ok := object.AsIterable(args[0])
+	if !ok {
+		return newError("argument to `take` must be iterable, got=%s",
+			args[0].Type())
+	}
+	n, ok := args[1].(*object.Integer)
+	if !ok || n.Value < 0 {
+		return newError("argument to `take` must be a non-negative INTEGER, got=%s",
+			args[1].Inspect())
+	}
+
+	elements := []object.Object{}
+	it.Reset()
+	defer closeIterable(it)
+	for int64(len(elements)) < n.Value {
+		val, _, ok := it.Next()
+		if !ok {
+			break
+		}
+		elements = append(elements, val)
+	}
+	if err := iterationError(it); err != nil {
+		return err
+	}
+	return &object.Array{Elements: elements}
+}
+
+func collectFun(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	it, ok := object.AsIterable(args[0])
+	if !ok {
+		return newError("argument to `collect` must be iterable, got=%s",
+			args[0].Type())
+	}
+
+	elements := []object.Object{}
+	it.Reset()
+	defer closeIterable(it)
+	for val, _, ok := it.Next(); ok; val, _, ok = it.Next() {
+		elements = append(elements, val)
+	}
+	if err := iterationError(it); err != nil {
+		return err
+	}
+	return &object.Array{Elements: elements}
+}
+
+func init() {
+	RegisterBuiltin("take",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (takeFun(args...))
+		})
+	RegisterBuiltin("collect",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (collectFun(args...))
+		})
+}
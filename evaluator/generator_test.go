@@ -0,0 +1,41 @@
+package evaluator
+
+import (
+	"testing"
+)
+
+func TestGenerators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`FUNC nat() { DEFER PRINT("closed "); LET n = 0; WHILE (TRUE) { YIELD n; n++; } }
+PRINT(take(nat(), 3));`, "closed [0, 1, 2]"},
+		{`FUNC two() { DEFER PRINT("closed "); YIELD 1; YIELD 2; }
+PRINT(collect(two()));`, "closed [1, 2]"},
+		{`FUNC two() { YIELD 1; YIELD 2; }
+LET g = two();
+PRINT(take(g, 1), g.next());`, "[1]null"},
+		{`
+FUNC nat() { LET n = 0; WHILE (TRUE) { YIELD n; n++; } }
+LET g = nat();
+LET sum = atomic();
+LET wg = waitgroup();
+wg.add(4);
+FOR (LET i = 0; i < 4; i++) {
+  spawn(FN() {
+    FOR (LET j = 0; j < 25; j++) { sum.add(g.next()); }
+    wg.done();
+  });
+}
+wg.wait();
+PRINT(sum.get(), " ", g.next());
+`, "4950 100"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
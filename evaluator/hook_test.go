@@ -0,0 +1,118 @@
+package evaluator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"scream/lexer"
+	"scream/object"
+	"scream/parser"
+)
+
+// recorder logs every hook invocation, vetoing those it is told to.
+type recorder struct {
+	events []string
+	veto   map[string]bool
+}
+
+func (r *recorder) record(event string) error {
+	r.events = append(r.events, event)
+	if r.veto[event] {
+		return errors.New("vetoed " + event)
+	}
+	return nil
+}
+
+func (r *recorder) BeforeCall(name string, args []object.Object) error {
+	return r.record(fmt.Sprintf("call %s%d", name, len(args)))
+}
+
+func (r *recorder) AfterCall(name string, args []object.Object, result object.Object) {
+	r.record(fmt.Sprintf("return %s %s", name, result.Inspect()))
+}
+
+func (r *recorder) BeforeSet(name string, val object.Object) error {
+	return r.record("set " + name)
+}
+
+func (r *recorder) OnError(err *object.Error) {
+	r.record("error " + err.Message)
+}
+
+func (r *recorder) BeforePrint(text string) error {
+	return r.record("print " + text)
+}
+
+func (r *recorder) BeforePragma(name string, enabled bool) error {
+	return r.record(fmt.Sprintf("pragma %s %t", name, enabled))
+}
+
+func runHooked(t *testing.T, input string, veto ...string) ([]string, string) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parse errors: %s", strings.Join(p.Errors(), "; "))
+	}
+
+	r := &recorder{veto: make(map[string]bool)}
+	for _, v := range veto {
+		r.veto[v] = true
+	}
+	var out bytes.Buffer
+	env := object.NewEnvironment()
+	env.SetStreams(object.NewStreams(strings.NewReader(""), &out, io.Discard))
+	env.AddHook(r)
+	Eval(program, env)
+	env.Streams().Flush()
+	return r.events, out.String()
+}
+
+func TestHooks(t *testing.T) {
+	tests := []struct {
+		input    string
+		veto     []string
+		events   []string
+		expected string
+	}{
+		{`FUNC f(x) { PRINT(x); } f(1);`, nil,
+			[]string{"set f", "call f1", "set x", "call PRINT1", "print 1", "return PRINT null", "return f null"}, "1"},
+		{`LET g = FN() { 2 }; g();`, nil,
+			[]string{"set g", "call FN0", "return FN 2"}, ""},
+		{`PRINT("a"); PRINT("b");`, []string{"print a"},
+			[]string{"call PRINT1", "print a", "return PRINT null", "call PRINT1", "print b", "return PRINT null"}, "b"},
+		{`LEN("abc");`, []string{"call LEN1"},
+			[]string{"call LEN1", "error vetoed call LEN1"}, ""},
+		{`LET x = 1; PRINT(x);`, []string{"set x"},
+			[]string{"set x", "error vetoed set x"}, ""},
+		// A vetoed parameter ends the call before its body runs.
+		{`FUNC f(x) { PRINT(x); } f(1); PRINT("after");`, []string{"set x"},
+			[]string{"set f", "call f1", "set x", "error vetoed set x", "return f ERROR: vetoed set x"}, ""},
+		{`FUNC f(...xs) { PRINT(xs); } f(1);`, []string{"set xs"},
+			[]string{"set f", "call f1", "set xs", "error vetoed set xs", "return f ERROR: vetoed set xs"}, ""},
+		{`pragma("strict");`, []string{"pragma strict true"},
+			[]string{"call pragma1", "pragma strict true", "return pragma []"}, ""},
+		// Each error reaches OnError once, however far it travels.
+		{`FUNC f() { LEN(1, 2) } FUNC g() { f() } g();`, nil,
+			[]string{"set f", "set g", "call g0", "call f0", "call LEN2",
+				"error wrong number of arguments. got=2, want=1", "return LEN ERROR: wrong number of arguments. got=2, want=1",
+				"return f ERROR: wrong number of arguments. got=2, want=1", "return g ERROR: wrong number of arguments. got=2, want=1"}, ""},
+		{`LET a = [1][5] + nope;`, nil,
+			[]string{"error identifier not found: nope"}, ""},
+	}
+
+	for _, tt := range tests {
+		events, out := runHooked(t, tt.input, tt.veto...)
+		if !reflect.DeepEqual(events, tt.events) {
+			t.Errorf("got events %q, want %q for %s", events, tt.events, tt.input)
+		}
+		if out != tt.expected {
+			t.Errorf("got output %q, want %q for %s", out, tt.expected, tt.input)
+		}
+	}
+}
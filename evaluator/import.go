@@ -0,0 +1,206 @@
+package evaluator
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"scream/ast"
+	"scream/lexer"
+	"scream/object"
+	"scream/parser"
+	"scream/token"
+)
+
+// ReadImport reads the files loaded by import().  Hosts may replace it,
+// for example to serve scripts bundled into an executable.
+var ReadImport = ioutil.ReadFile
+
+// ScriptPath is the path of the script being run, against whose
+// directory relative imports are resolved.
+var ScriptPath string
+
+// OnLoad, when non-nil, is called with the path of every file read by
+// import() or opened for reading by open().
+var OnLoad func(path string)
+
+// importMu guards importing and imported, as tasks may import files at
+// the same time.
+var importMu sync.Mutex
+
+// importing lists the files currently being imported, innermost last.
+var importing []string
+
+// imported records each file which has been imported.
+var imported = make(map[string]bool)
+
+// ImportPath resolves the path given to import() from the file named
+// by from.
+func ImportPath(from string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(filepath.Dir(from), path)
+}
+
+// Imports returns the literal paths given to import() in program.
+func Imports(program *ast.Program) []string {
+	var paths []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpression)
+		if !ok || len(call.Arguments) != 1 {
+			return true
+		}
+		fn, ok := call.Function.(*ast.Identifier)
+		if !ok || fn.Value != "import" {
+			return true
+		}
+		if path, ok := call.Arguments[0].(*ast.StringLiteral); ok {
+			paths = append(paths, path.Value)
+		}
+		return true
+	})
+	return paths
+}
+
+func importFun(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	name, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument to `import` must be STRING, got=%s",
+			args[0].Type())
+	}
+
+	importMu.Lock()
+	from := ScriptPath
+	if len(importing) > 0 {
+		from = importing[len(importing)-1]
+	}
+	path := ImportPath(from, name.Value)
+	done := imported[path]
+	importMu.Unlock()
+	if done {
+		return FALSE
+	}
+
+	if OnLoad != nil {
+		OnLoad(path)
+	}
+	input, err := ReadImport(path)
+	if err != nil {
+		return newError("import failed: %s", err.Error())
+	}
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError("import of %s failed: %s", path, strings.Join(p.Errors(), "; "))
+	}
+
+	BindLocation(program, path)
+
+	// Another task may have imported the file while it was being read.
+	importMu.Lock()
+	if imported[path] {
+		importMu.Unlock()
+		return FALSE
+	}
+	imported[path] = true
+	importing = append(importing, path)
+	importMu.Unlock()
+
+	res := Eval(program, env)
+
+	// Tasks importing files at the same time may have added to importing
+	// since.
+	importMu.Lock()
+	for i := len(importing) - 1; i >= 0; i-- {
+		if importing[i] == path {
+			importing = append(importing[:i], importing[i+1:]...)
+			break
+		}
+	}
+	importMu.Unlock()
+	if isError(res) {
+		return object.Wrap(res.(*object.Error), "import of %s failed", path)
+	}
+	return TRUE
+}
+
+func init() {
+	RegisterBuiltin("import",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (importFun(env, args...))
+		})
+}
+
+// Reset forgets which files have been imported and which pragmas are
+// enabled, so that a script can be run again from scratch.
+func Reset() {
+	importMu.Lock()
+	imported = make(map[string]bool)
+	importing = nil
+	importMu.Unlock()
+	pragmaMu.Lock()
+	for name := range PRAGMAS {
+		delete(PRAGMAS, name)
+	}
+	pragmaMu.Unlock()
+}
+
+// BindLocation replaces the __FILE__ and __DIR__ constants in program
+// with the path it was read from and that path's directory, so they
+// name the file they appear in even within imported functions.
+func BindLocation(program *ast.Program, path string) {
+	values := map[string]string{
+		"__FILE__": path,
+		"__DIR__":  filepath.Dir(path),
+	}
+
+	// Names being bound, rather than read, are left alone.
+	bound := make(map[*ast.Identifier]bool)
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			bound[n.Name] = true
+		case *ast.ConstStatement:
+			bound[n.Name] = true
+		case *ast.AssignStatement:
+			bound[n.Name] = true
+		case *ast.FunctionLiteral:
+			for _, p := range n.Parameters {
+				bound[p] = true
+			}
+		case *ast.FunctionDefineLiteral:
+			for _, p := range n.Parameters {
+				bound[p] = true
+			}
+		case *ast.ArrayPattern, *ast.HashPattern, *ast.VariablePattern:
+			for _, id := range ast.PatternNames(n.(ast.Expression)) {
+				bound[id] = true
+			}
+		case *ast.ObjectCallExpression:
+			if call, ok := n.Call.(*ast.CallExpression); ok {
+				if name, ok := call.Function.(*ast.Identifier); ok {
+					bound[name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	ast.Rewrite(program, func(node ast.Node) ast.Node {
+		id, ok := node.(*ast.Identifier)
+		if !ok || bound[id] {
+			return node
+		}
+		if value, ok := values[id.Value]; ok {
+			tok := token.Token{Type: token.STRING, Literal: value, Line: id.Token.Line, Column: id.Token.Column}
+			return &ast.StringLiteral{Token: tok, Value: value}
+		}
+		return node
+	})
+}
@@ -0,0 +1,31 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"scream/ast"
+)
+
+// Lint returns warnings about code in program which is valid but likely
+// to be mistaken.  For now that is a switch with no default case, which
+// may match none of its cases and so evaluate to NULL.
+func Lint(program *ast.Program) []string {
+	var warnings []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		if se, ok := node.(*ast.SwitchExpression); ok && !hasDefaultCase(se) {
+			warnings = append(warnings, fmt.Sprintf("switch around line %d, column %d may match no case; add a default",
+				se.Token.Line, se.Token.Column))
+		}
+		return true
+	})
+	return warnings
+}
+
+func hasDefaultCase(se *ast.SwitchExpression) bool {
+	for _, opt := range se.Choices {
+		if opt.Default {
+			return true
+		}
+	}
+	return false
+}
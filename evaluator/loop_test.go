@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"scream/lexer"
+	"scream/object"
+	"scream/parser"
+)
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`FOR (LET I = 0; I < 3; I++) { PRINT(I); }`, "012"},
+		{`FOR (LET I = 0; I < 5; I++) { IF (I == 2) { CONTINUE; } PRINT(I); }`, "0134"},
+		{`FOR (LET I = 0; I < 5; I++) { IF (I == 2) { BREAK; } PRINT(I); }`, "01"},
+		{`LET N = 0; FOR (; N < 3; N++) { } PRINT(N);`, "3"},
+		{`LET N = 0; FOR (LET I = 0; I < 4; I++) { N = N + I; } PRINT(N);`, "6"},
+		{`FUNC f() { FOR (LET I = 0; ; I++) { IF (I == 4) { RETURN I; } } } PRINT(f());`, "4"},
+		{`FOR (LET I = 0; I < 3; I++) { FOR (LET J = 0; J < 3; J++) { IF (J == 1) { BREAK; } PRINT(I, J); } }`, "001020"},
+		{`FOR (LET I = 0; I < 2; I++) { FOR (LET J = 0; J < 3; J++) { IF (J == 1) { CONTINUE; } PRINT(I, J); } }`, "00021012"},
+		{`LET J = 0; WHILE (J < 10) { J++; IF (J % 2 == 0) { CONTINUE; } IF (J > 7) { BREAK; } PRINT(J); }`, "1357"},
+		{`LET I = 0; WHILE (I < 2) { I++; FOR (LET J = 0; J < 9; J++) { IF (J == I) { BREAK; } PRINT(J); } }`, "001"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`BREAK;`, "BREAK outside of a loop"},
+		{`CONTINUE;`, "CONTINUE outside of a loop"},
+		{`FUNC g() { CONTINUE; } g();`, "CONTINUE outside of a loop"},
+		{`LET f = FN() { IF (TRUE) { BREAK; } }; f();`, "BREAK outside of a loop"},
+		{`FOR (LET I = 0; I < 2; I++) { } I;`, "I"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.SetStreams(object.NewStreams(strings.NewReader(""), io.Discard, io.Discard))
+		res := Eval(program, env)
+		if !isError(res) || !strings.Contains(res.Inspect(), tt.expected) {
+			t.Errorf("got %s, want an error about %q for %s", res.Inspect(), tt.expected, tt.input)
+		}
+	}
+}
+
+// TestLoopErrors checks that an error ends the loop it occurs in, and
+// everything around it, rather than only the current iteration.
+func TestLoopErrors(t *testing.T) {
+	tests := []string{
+		`FOR (LET i = 0; i < 3; i++) { PRINT(i); 1 + "a"; } PRINT("after");`,
+		`LET i = 0; WHILE (i < 3) { PRINT(i); i++; 1 + "a"; } PRINT("after");`,
+		`foreach i in [0, 1] { PRINT(i); 1 + "a"; } PRINT("after");`,
+		`FOR (LET i = 0; i < 3; i++) { foreach j in [0] { PRINT(i); 1 + "a"; } } PRINT("after");`,
+		`FUNC f() { WHILE (TRUE) { PRINT(0); 1 + "a"; } RETURN 1; } f(); PRINT("after");`,
+	}
+
+	for _, input := range tests {
+		var out bytes.Buffer
+		program := parser.New(lexer.New(input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.SetStreams(object.NewStreams(strings.NewReader(""), &out, io.Discard))
+		res := Eval(program, env)
+		env.Streams().Flush()
+		if !isError(res) || !strings.Contains(res.Inspect(), "type mismatch") {
+			t.Errorf("got %s, want a type mismatch for %s", res.Inspect(), input)
+		}
+		if got := out.String(); got != "0Error: ERROR: type mismatch: INTEGER + STRING\n" {
+			t.Errorf("kept running after the error, printing %q for %s", got, input)
+		}
+	}
+}
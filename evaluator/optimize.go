@@ -0,0 +1,261 @@
+package evaluator
+
+import (
+	"math"
+	"strconv"
+
+	"scream/ast"
+	"scream/object"
+	"scream/token"
+)
+
+// Optimize rewrites a parsed program in place before it is evaluated.
+// It folds arithmetic, comparisons and string concatenation over
+// literals, drops IF, ternary and switch branches which can never be
+// taken, and inlines the value of literal CONST definitions.  Anything
+// which would fail at runtime, such as an integer division by zero, is
+// left alone so the error still happens when the program runs.
+func Optimize(program *ast.Program) *ast.Program {
+	o := &optimizer{methods: make(map[*ast.Identifier]bool)}
+	ast.Inspect(program, func(node ast.Node) bool {
+		if call, ok := node.(*ast.ObjectCallExpression); ok {
+			if method, ok := call.Call.(*ast.CallExpression); ok {
+				if name, ok := method.Function.(*ast.Identifier); ok {
+					o.methods[name] = true
+				}
+			}
+		}
+		return true
+	})
+
+	consts := o.constantValues(program)
+	for i, stmt := range program.Statements {
+		o.consts = make(map[string]ast.Expression)
+		for name, c := range consts {
+			if c.index < i {
+				o.consts[name] = c.value
+			}
+		}
+		program.Statements[i] = ast.Rewrite(stmt, o.optimizeNode).(ast.Statement)
+	}
+	return program
+}
+
+type optimizer struct {
+	// consts holds the CONST values visible to the current statement.
+	consts map[string]ast.Expression
+
+	// methods holds the identifiers naming a method in an object call,
+	// which must never be replaced.
+	methods map[*ast.Identifier]bool
+}
+
+type constant struct {
+	index int
+	value ast.Expression
+}
+
+// constantValues finds top-level CONST statements with a literal value
+// whose name is never bound anywhere else, so every later reference
+// must see that value.
+func (o *optimizer) constantValues(program *ast.Program) map[string]constant {
+	bound := make(map[string]int)
+	ast.Inspect(program, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.LetStatement:
+			bound[n.Name.Value]++
+		case *ast.ConstStatement:
+			bound[n.Name.Value]++
+		case *ast.AssignStatement:
+			bound[n.Name.Value]++
+		case *ast.PostfixExpression:
+			bound[n.Token.Literal]++
+		case *ast.FunctionDefineLiteral:
+			bound[n.TokenLiteral()]++
+			for _, p := range n.Parameters {
+				bound[p.Value]++
+			}
+		case *ast.FunctionLiteral:
+			for _, p := range n.Parameters {
+				bound[p.Value]++
+			}
+		case *ast.ForeachStatement:
+			bound[n.Ident]++
+			bound[n.Index]++
+		case *ast.ArrayPattern, *ast.HashPattern, *ast.VariablePattern:
+			for _, id := range ast.PatternNames(n.(ast.Expression)) {
+				bound[id.Value]++
+			}
+		}
+		return true
+	})
+
+	consts := make(map[string]constant)
+	for i, stmt := range program.Statements {
+		c, ok := stmt.(*ast.ConstStatement)
+		if !ok || c.Name == nil || bound[c.Name.Value] != 1 {
+			continue
+		}
+		value := ast.Rewrite(c.Value, o.optimizeNode)
+		if literalObject(value) != nil {
+			consts[c.Name.Value] = constant{index: i, value: value.(ast.Expression)}
+		}
+	}
+	return consts
+}
+
+func (o *optimizer) optimizeNode(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		if value, ok := o.consts[n.Value]; ok && !o.methods[n] {
+			return objectLiteral(literalObject(value), n.Token)
+		}
+	case *ast.PrefixExpression:
+		right := literalObject(n.Right)
+		if right == nil {
+			return n
+		}
+		if res := objectLiteral(evalPrefixExpression(n.Operator, right), n.Token); res != nil {
+			return res
+		}
+	case *ast.InfixExpression:
+		return foldInfix(n)
+	case *ast.IfExpression:
+		cond := literalObject(n.Condition)
+		if cond == nil {
+			return n
+		}
+		if !isTruthy(cond) {
+			if n.Alternative == nil {
+				return objectLiteral(NULL, n.Token)
+			}
+			n.Consequence = n.Alternative
+		}
+		n.Condition = &ast.Boolean{Token: token.Token{Type: token.TRUE, Literal: "TRUE", Line: n.Token.Line, Column: n.Token.Column}, Value: true}
+		n.Alternative = nil
+	case *ast.TernaryExpression:
+		cond := literalObject(n.Condition)
+		if cond == nil {
+			return n
+		}
+		if isTruthy(cond) {
+			return n.IfTrue
+		}
+		return n.IfFalse
+	case *ast.SwitchExpression:
+		return foldSwitch(n)
+	}
+	return node
+}
+
+func foldInfix(n *ast.InfixExpression) ast.Node {
+	left := literalObject(n.Left)
+	right := literalObject(n.Right)
+	if left == nil || right == nil {
+		return n
+	}
+
+	// Integer division by zero panics at runtime; keep it there.
+	if r, ok := right.(*object.Integer); ok && r.Value == 0 && left.Type() == object.INTEGER_OBJ {
+		switch n.Operator {
+		case "/", "%":
+			return n
+		}
+	}
+
+	res := evalInfixExpression(n.Operator, left, right, nil)
+	if isError(res) {
+		return n
+	}
+	if lit := objectLiteral(res, n.Token); lit != nil {
+		return lit
+	}
+	return n
+}
+
+// foldSwitch resolves a switch on a literal subject whose cases are all
+// literals, keeping only the branch which will run.
+func foldSwitch(n *ast.SwitchExpression) ast.Node {
+	subject := literalObject(n.Value)
+	if subject == nil {
+		return n
+	}
+
+	var match, fallback *ast.CaseExpression
+	for _, opt := range n.Choices {
+		if opt.Default {
+			fallback = opt
+			continue
+		}
+		if opt.Guard != nil {
+			return n
+		}
+		for _, e := range opt.Expr {
+			val := literalObject(e)
+			if val == nil {
+				return n
+			}
+			if match == nil && val.Type() == subject.Type() && val.Inspect() == subject.Inspect() {
+				match = opt
+			}
+		}
+	}
+	if match == nil {
+		match = fallback
+	}
+
+	n.Choices = nil
+	if match != nil {
+		n.Choices = []*ast.CaseExpression{{Token: match.Token, Default: true, Block: match.Block}}
+	}
+	return n
+}
+
+// literalObject returns the value of a literal node, or nil if the node
+// is not a literal.
+func literalObject(node ast.Node) object.Object {
+	switch n := node.(type) {
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: n.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: n.Value}
+	case *ast.StringLiteral:
+		return &object.String{Value: n.Value}
+	case *ast.Boolean:
+		return nativeBoolToBooleanObject(n.Value)
+	case *ast.NullLiteral:
+		return NULL
+	}
+	return nil
+}
+
+// objectLiteral converts a folded value back into a literal node placed
+// at the position of the expression it replaces.
+func objectLiteral(obj object.Object, pos token.Token) ast.Expression {
+	tok := token.Token{Line: pos.Line, Column: pos.Column}
+	switch obj := obj.(type) {
+	case *object.Integer:
+		tok.Type, tok.Literal = token.INT, strconv.FormatInt(obj.Value, 10)
+		return &ast.IntegerLiteral{Token: tok, Value: obj.Value}
+	case *object.Float:
+		if math.IsInf(obj.Value, 0) || math.IsNaN(obj.Value) {
+			return nil
+		}
+		tok.Type, tok.Literal = token.FLOAT, obj.Inspect()
+		return &ast.FloatLiteral{Token: tok, Value: obj.Value}
+	case *object.String:
+		tok.Type, tok.Literal = token.STRING, obj.Value
+		return &ast.StringLiteral{Token: tok, Value: obj.Value}
+	case *object.Boolean:
+		if obj.Value {
+			tok.Type, tok.Literal = token.TRUE, "TRUE"
+		} else {
+			tok.Type, tok.Literal = token.FALSE, "FALSE"
+		}
+		return &ast.Boolean{Token: tok, Value: obj.Value}
+	case *object.Null:
+		tok.Type, tok.Literal = token.NULL, "NIL"
+		return &ast.NullLiteral{Token: tok}
+	}
+	return nil
+}
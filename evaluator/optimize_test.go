@@ -0,0 +1,85 @@
+package evaluator
+
+import (
+	"testing"
+
+	"scream/ast"
+	"scream/lexer"
+	"scream/parser"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`LET x = 2 * 60 * 60;`, `LET x = 7200;`},
+		{`LET x = "a" + "b" + "c";`, `LET x = abc;`},
+		{`LET x = 1.5 * 2;`, `LET x = 3;`},
+		{`LET x = -(3 + 4) < 0;`, `LET x = TRUE;`},
+		{`const N = 4; LET x = N * N;`, `const N = 4;LET x = 16;`},
+		{`const N = 4; LET N = 5; LET x = N;`, `const N = 4;LET N = 5;LET x = N;`},
+		{`IF (FALSE) { PRINT(1); }`, `NIL`},
+		{`IF (1 > 2) { PRINT(1); } ELSE { PRINT(2); }`, `ifTRUE PRINT(2)`},
+		{`LET x = TRUE ? "yes" : "no";`, `LET x = yes;`},
+		{`LET x = 1 / 0;`, `LET x = (1 / 0);`},
+		{`LET x = 7 % 0;`, `LET x = (7 % 0);`},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if got := Optimize(program).String(); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+func TestOptimizeSwitch(t *testing.T) {
+	tests := []struct {
+		input    string
+		choices  int
+		expected string
+	}{
+		{`switch (2) { case 1 { PRINT("one"); } case 2 { PRINT("two"); } default { PRINT("other"); } }`, 1, "two"},
+		{`switch (1 + 2) { case 1 { PRINT("one"); } default { PRINT("other"); } }`, 1, "other"},
+		{`LET X = switch ("b") { case "a" { 1 } }; PRINT(X);`, 0, "null"},
+		{`switch (5) { case 1..9 { PRINT("digit"); } default { PRINT("other"); } }`, 2, "digit"},
+		{`switch (5) { case N if N > 9 { PRINT("big"); } default { PRINT("small"); } }`, 2, "small"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := Optimize(p.ParseProgram())
+		var choices int
+		ast.Inspect(program, func(node ast.Node) bool {
+			if se, ok := node.(*ast.SwitchExpression); ok {
+				choices = len(se.Choices)
+			}
+			return true
+		})
+		if choices != tt.choices {
+			t.Errorf("got %d cases, want %d for %s", choices, tt.choices, tt.input)
+		}
+		if got := runProgram(t, program); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+// TestOptimizeKeepsErrors checks that code which fails at runtime still
+// does once optimized, rather than failing, or not, while folding.
+// Integer division by zero is kept as it is by TestOptimize.
+func TestOptimizeKeepsErrors(t *testing.T) {
+	for _, input := range []string{
+		`PRINT(try(FN() { "a" - 1 }).message());`,
+		`PRINT(try(FN() { TRUE + 1 }).message());`,
+		`PRINT(try(FN() { LEN(1, 2) + 1 }).message());`,
+	} {
+		p := parser.New(lexer.New(input))
+		want := runScript(t, input)
+		if got := runProgram(t, Optimize(p.ParseProgram())); got != want || got == "" {
+			t.Errorf("got %q, want %q for %s", got, want, input)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package evaluator
+
+import (
+	"scream/ast"
+	"scream/object"
+)
+
+// bindPattern sets each name in pattern, within env, to the part of val
+// it matches, returning an error if val does not fit.  Names with nothing
+// to match are set to their default, if they have one, or NULL; defaults
+// may refer to names bound before them.
+func bindPattern(pattern ast.Expression, val object.Object, env *object.Environment) object.Object {
+	_, err := destructure(pattern, val, env, false)
+	return err
+}
+
+// matchPattern is bindPattern for switch cases: rather than failing it
+// reports whether val fits, which is stricter.  Arrays must have neither
+// more elements than the pattern, unless it has a rest, nor fewer, bar
+// those with defaults; hashes must have every key without a default.
+func matchPattern(pattern ast.Expression, val object.Object, env *object.Environment) (bool, object.Object) {
+	return destructure(pattern, val, env, true)
+}
+
+func destructure(pattern ast.Expression, val object.Object, env *object.Environment, strict bool) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if res := env.Set(pattern.Value, val); isError(res) {
+			return false, res
+		}
+		return true, nil
+	case *ast.ArrayPattern:
+		return destructureArray(pattern, val, env, strict)
+	case *ast.HashPattern:
+		return destructureHash(pattern, val, env, strict)
+	}
+
+	want := Eval(pattern, env)
+	if isError(want) {
+		return false, want
+	}
+	if sameValue(val, want) {
+		return true, nil
+	}
+	return mismatch(pattern, val, strict)
+}
+
+// mismatch reports that val does not fit pattern: as an error, unless
+// matching strictly.
+func mismatch(pattern ast.Expression, val object.Object, strict bool) (bool, object.Object) {
+	if strict {
+		return false, nil
+	}
+	return false, newError("cannot destructure %s with %s", val.Type(), pattern.String())
+}
+
+func destructureArray(pattern *ast.ArrayPattern, val object.Object, env *object.Environment, strict bool) (bool, object.Object) {
+	var elements []object.Object
+	switch val := val.(type) {
+	case *object.Array:
+		elements = val.Elements
+	default:
+		// Matching must not consume a generator which may then fail
+		// to match.
+		it, ok := object.AsFreshIterable(val)
+		if !ok || strict {
+			return mismatch(pattern, val, strict)
+		}
+		// Without a rest element only as many values as the pattern
+		// binds are read, so an endless generator may be destructured.
+		it.Reset()
+		for pattern.Rest != nil || len(elements) < len(pattern.Elements) {
+			v, _, ok := it.Next()
+			if !ok {
+				break
+			}
+			elements = append(elements, v)
+		}
+		if err := iterationError(it); err != nil {
+			return false, err
+		}
+	}
+	if strict && pattern.Rest == nil && len(elements) > len(pattern.Elements) {
+		return false, nil
+	}
+
+	for i, target := range pattern.Elements {
+		var part object.Object
+		if i < len(elements) {
+			part = elements[i]
+		} else if strict && !hasDefault(target, pattern.Defaults) {
+			return false, nil
+		}
+		if ok, err := destructureTarget(target, part, pattern.Defaults, env, strict); !ok {
+			return false, err
+		}
+	}
+	if pattern.Rest != nil {
+		var rest []object.Object
+		if len(elements) > len(pattern.Elements) {
+			rest = append(rest, elements[len(pattern.Elements):]...)
+		}
+		if res := env.Set(pattern.Rest.Value, &object.Array{Elements: rest}); isError(res) {
+			return false, res
+		}
+	}
+	return true, nil
+}
+
+func destructureHash(pattern *ast.HashPattern, val object.Object, env *object.Environment, strict bool) (bool, object.Object) {
+	hash, ok := val.(*object.Hash)
+	if !ok {
+		return mismatch(pattern, val, strict)
+	}
+
+	used := make(map[object.HashKey]bool)
+	for i, key := range pattern.Keys {
+		hk := (&object.String{Value: key}).HashKey()
+		used[hk] = true
+		var part object.Object
+		if pair, ok := hash.Pairs[hk]; ok {
+			part = pair.Value
+		} else if strict && !hasDefault(pattern.Targets[i], pattern.Defaults) {
+			return false, nil
+		}
+		if ok, err := destructureTarget(pattern.Targets[i], part, pattern.Defaults, env, strict); !ok {
+			return false, err
+		}
+	}
+	if pattern.Rest != nil {
+		rest := &object.Hash{Pairs: make(map[object.HashKey]object.HashPair)}
+		for hk, pair := range hash.Pairs {
+			if !used[hk] {
+				rest.Pairs[hk] = pair
+			}
+		}
+		if res := env.Set(pattern.Rest.Value, rest); isError(res) {
+			return false, res
+		}
+	}
+	return true, nil
+}
+
+// destructureTarget binds part of a value, or its default if part is
+// nil, to one target of a pattern.
+func destructureTarget(target ast.Expression, part object.Object, defaults map[string]ast.Expression, env *object.Environment, strict bool) (bool, object.Object) {
+	if part == nil {
+		part = NULL
+		if id, ok := target.(*ast.Identifier); ok {
+			if def, ok := defaults[id.Value]; ok {
+				part = Eval(def, env)
+				if isError(part) {
+					return false, part
+				}
+			}
+		}
+	}
+	return destructure(target, part, env, strict)
+}
+
+func hasDefault(target ast.Expression, defaults map[string]ast.Expression) bool {
+	if id, ok := target.(*ast.Identifier); ok {
+		_, ok := defaults[id.Value]
+		return ok
+	}
+	return false
+}
+
+// sameValue reports whether two values are equal as a switch compares
+// them: by type and printed form.
+func sameValue(a, b object.Object) bool {
+	return a.Type() == b.Type() && a.Inspect() == b.Inspect()
+}
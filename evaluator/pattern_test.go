@@ -0,0 +1,57 @@
+package evaluator
+
+import (
+	"testing"
+)
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`LET [A, B, ...REST] = [1, 2, 3, 4]; PRINT(A, B, REST);`, "12[3, 4]"},
+		{`LET [X, Y = X + 10, Z] = [5]; PRINT(X, Y, Z);`, "515null"},
+		{`LET {name, "exit-code": CODE = 0, ...OTHER} = {"name": "bob", "x": 1}; PRINT(name, CODE, OTHER);`,
+			"bob0{x: 1}"},
+		{`LET [[P, Q], {k: K}] = [[1, 2], {"k": "v"}]; PRINT(P, Q, K);`, "12v"},
+		{`LET [F, ...R] = 1..3; PRINT(F, R);`, "1[2, 3]"},
+		{`foreach [K, V] in [["a", 1], ["b", 2]] { PRINT(K, V); }`, "a1b2"},
+		{`foreach I, {name} in [{"name": "x"}, {"name": "y"}] { PRINT(I, name); }`, "0x1y"},
+		{`FUNC f([A, B], {c = 9}, D = 4) { PRINT(A, B, c, D); } f([1, 2], {}); f([1, 2], {"c": 3}, 7);`,
+			"12941237"},
+		{`FUNC f([A]) { A } PRINT(try(f, 1).message());`,
+			"bad argument to `f([A])`: cannot destructure INTEGER with [A]"},
+		{`PRINT(try(FN() { LET {a} = [1]; }).message());`, "cannot destructure ARRAY with {a}"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+// TestDestructuringGenerators checks that only the values bound are read
+// from a generator, unless a rest element asks for all of them.
+func TestDestructuringGenerators(t *testing.T) {
+	generators := `FUNC nat() { LET n = 0; WHILE (TRUE) { YIELD n; n++; } }
+FUNC two() { YIELD 1; YIELD 2; }
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`LET [A, B] = nat(); PRINT(A, B);`, "01"},
+		{`LET [[A]] = [nat()]; PRINT(A);`, "0"},
+		{`LET g = nat(); LET [A] = g; LET [B, C] = g; PRINT(A, B, C);`, "012"},
+		{`LET [A, B, C] = two(); PRINT(A, B, C);`, "12null"},
+		{`LET [A, ...REST] = two(); PRINT(A, REST);`, "1[2]"},
+		{`foreach [A, B] in [nat(), nat()] { PRINT(A, B); }`, "0101"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, generators+tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package evaluator
+
+import (
+	"testing"
+)
+
+func TestRanges(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`PRINT(1..4, " ", (1..4).to_array(), " ", LEN(1..4));`, "1..4 [1, 2, 3, 4] 4"},
+		{`PRINT((4..1).to_array(), " ", (1..10 step 4).to_array(), " ", (10..1 step 4).to_array());`,
+			"[4, 3, 2, 1] [1, 5, 9] [10, 6, 2]"},
+		{`LET r = 1..9 step 2; PRINT(r.contains(5), r.contains(4), r.len(), r[2]);`, "truefalse55"},
+		{`LET n = 0; foreach i in 1..100000 { n = n + 1; } PRINT(n);`, "100000"},
+		{`PRINT(APPEND(1..2, 3), " ", type(1..3));`, "[1, 2, 3] array"},
+		// step is only special after a range.
+		{`LET step = 3; PRINT(step, " ", 1..7 step step);`, "3 1..7 step 3"},
+		{`FUNC step(n) { n * 2 } PRINT(step(2));`, "4"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+// TestRangeArrayMethods checks that methods defined for arrays may be
+// called on ranges, which are given the elements as self.
+func TestRangeArrayMethods(t *testing.T) {
+	methods := `FUNC array.sum() { LET s = 0; foreach x in self { s = s + x; } s }
+FUNC array.head() { self[0] }
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`PRINT((1..3).sum(), " ", [1, 2, 3].sum());`, "6 6"},
+		{`PRINT((5..1 step 2).head(), " ", (1..4).len());`, "5 4"},
+		{`PRINT((1..2).methods());`, "[contains, head, len, methods, sum, to_array]"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, methods+tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"scream/ast"
+	"scream/object"
+)
+
+// dynamicBuiltins can introduce names at runtime which no static pass
+// can see, into the scope of the function, or program, calling them.
+var dynamicBuiltins = map[string]bool{
+	"eval":   true,
+	"import": true,
+}
+
+// scope mirrors one runtime environment.  Function and program scopes
+// own every name assigned within them; temporary scopes, like those of
+// IF and foreach, own only the names they permit and pass the rest on
+// to their outer scope, just as object.NewTemporaryScope does.  A
+// dynamic scope is one which eval or import may add names to.
+type scope struct {
+	outer     *scope
+	temporary bool
+	dynamic   bool
+	names     map[string]bool
+}
+
+func newScope(outer *scope, temporary bool, names ...string) *scope {
+	s := &scope{outer: outer, temporary: temporary, names: make(map[string]bool)}
+	for _, name := range names {
+		s.names[name] = true
+	}
+	return s
+}
+
+// declare adds name to the scope which will hold it at runtime.
+func (s *scope) declare(name string) {
+	for s.temporary {
+		if s.names[name] {
+			return
+		}
+		s = s.outer
+	}
+	s.names[name] = true
+}
+
+// owner returns the scope which holds the names assigned within s.
+func (s *scope) owner() *scope {
+	for s.temporary {
+		s = s.outer
+	}
+	return s
+}
+
+type resolver struct {
+	scope  *scope
+	env    *object.Environment
+	errors []string
+}
+
+// Resolve binds every identifier referenced by program to the scope
+// declaring it, so evaluation can go straight to the right environment
+// rather than searching outwards, and reports names which are declared
+// nowhere.  Names already present in env, such as those defined by the
+// prelude, and builtins are known.  Within the environment found, names
+// are still looked up by name, since eval and import may add to it.
+//
+// A name which could be declared by a call of eval or import in a scope
+// between the reference and its declaration is left to be looked up at
+// runtime, and is not reported if it is declared nowhere else.
+func Resolve(program *ast.Program, env *object.Environment) []string {
+	r := &resolver{scope: newScope(nil, false), env: env}
+	r.hoist(program)
+	ast.Walk(r, program)
+	return r.errors
+}
+
+// hoist declares every name assigned within the body of the current
+// scope, without descending into nested functions, so that references
+// resolve no matter where in the body the assignment appears.
+func (r *resolver) hoist(body ast.Node) {
+	ast.Walk(&hoister{scope: r.scope}, body)
+}
+
+type hoister struct {
+	scope *scope
+}
+
+func (h *hoister) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		if dynamicBuiltins[n.Value] {
+			h.scope.owner().dynamic = true
+		}
+	case *ast.LetStatement:
+		h.scope.declare(n.Name.Value)
+	case *ast.DestructuringStatement:
+		for _, name := range patternNames(n.Pattern) {
+			h.scope.declare(name)
+		}
+	case *ast.ConstStatement:
+		h.scope.declare(n.Name.Value)
+	case *ast.AssignStatement:
+		h.scope.declare(n.Name.Value)
+	case *ast.PostfixExpression:
+		h.scope.declare(n.Token.Literal)
+	case *ast.FunctionDefineLiteral:
+		h.scope.declare(n.TokenLiteral())
+		return nil
+	case *ast.FunctionLiteral:
+		return nil
+	case *ast.IfExpression:
+		return &hoister{scope: newScope(h.scope, true, captureNames()...)}
+	case *ast.CaseExpression:
+		if names := caseNames(n); !n.Default && names != nil {
+			return &hoister{scope: newScope(h.scope, true, names...)}
+		}
+	case *ast.ForeachStatement:
+		ast.Walk(h, n.Value)
+		ast.Walk(&hoister{scope: newScope(h.scope, true, foreachNames(n)...)}, n.Body)
+		return nil
+	case *ast.CountedForExpression:
+		if names := countedForNames(n); names != nil {
+			return &hoister{scope: newScope(h.scope, true, names...)}
+		}
+	}
+	return h
+}
+
+func (r *resolver) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		r.resolve(n)
+		return nil
+	case *ast.LetStatement:
+		ast.Walk(r, n.Value)
+		return nil
+	case *ast.DestructuringStatement:
+		ast.Walk(r, n.Value)
+		ast.Walk(r, n.Pattern)
+		return nil
+	case *ast.ArrayPattern:
+		r.patternDefaults(n.Elements, n.Defaults)
+		return nil
+	case *ast.HashPattern:
+		r.patternDefaults(n.Targets, n.Defaults)
+		return nil
+	case *ast.ConstStatement:
+		ast.Walk(r, n.Value)
+		return nil
+	case *ast.AssignStatement:
+		ast.Walk(r, n.Value)
+		return nil
+	case *ast.ObjectCallExpression:
+		ast.Walk(r, n.Object)
+		if call, ok := n.Call.(*ast.CallExpression); ok {
+			for _, arg := range call.Arguments {
+				ast.Walk(r, arg)
+			}
+		}
+		return nil
+	case *ast.IfExpression:
+		r.push(newScope(r.scope, true, captureNames()...))
+		ast.Walk(r, n.Condition)
+		ast.Walk(r, n.Consequence)
+		ast.Walk(r, n.Alternative)
+		r.pop()
+		return nil
+	case *ast.CaseExpression:
+		names := caseNames(n)
+		if n.Default || names == nil {
+			return r
+		}
+		r.push(newScope(r.scope, true, names...))
+		for _, e := range n.Expr {
+			ast.Walk(r, e)
+		}
+		ast.Walk(r, n.Guard)
+		ast.Walk(r, n.Block)
+		r.pop()
+		return nil
+	case *ast.ForeachStatement:
+		ast.Walk(r, n.Value)
+		r.push(newScope(r.scope, true, foreachNames(n)...))
+		ast.Walk(r, n.Pattern)
+		ast.Walk(r, n.Body)
+		r.pop()
+		return nil
+	case *ast.CountedForExpression:
+		names := countedForNames(n)
+		if names == nil {
+			return r
+		}
+		r.push(newScope(r.scope, true, names...))
+		ast.Walk(r, n.Init)
+		ast.Walk(r, n.Condition)
+		ast.Walk(r, n.Post)
+		ast.Walk(r, n.Body)
+		r.pop()
+		return nil
+	case *ast.FunctionLiteral:
+		r.function(n.Parameters, n.Defaults, n.Patterns, n.Body)
+		return nil
+	case *ast.FunctionDefineLiteral:
+		r.function(n.Parameters, n.Defaults, n.Patterns, n.Body)
+		return nil
+	case nil:
+		return nil
+	}
+	return r
+}
+
+// function resolves a function body in a fresh scope holding its
+// parameters, the names their patterns bind, their defaults, and "self"
+// for functions used as methods.
+func (r *resolver) function(params []*ast.Identifier, defaults, patterns map[string]ast.Expression, body *ast.BlockStatement) {
+	r.push(newScope(r.scope, false, "self"))
+	for _, p := range params {
+		r.scope.declare(p.Value)
+		for _, name := range patternNames(patterns[p.Value]) {
+			r.scope.declare(name)
+		}
+	}
+	for _, p := range params {
+		r.hoist(defaults[p.Value])
+	}
+	r.hoist(body)
+	for _, p := range params {
+		ast.Walk(r, patterns[p.Value])
+		if def, ok := defaults[p.Value]; ok {
+			ast.Walk(r, def)
+		}
+	}
+	ast.Walk(r, body)
+	r.pop()
+}
+
+// patternDefaults resolves the defaults within a pattern, leaving the
+// names it binds alone.
+func (r *resolver) patternDefaults(targets []ast.Expression, defaults map[string]ast.Expression) {
+	for _, t := range targets {
+		if id, ok := t.(*ast.Identifier); ok {
+			ast.Walk(r, defaults[id.Value])
+		} else {
+			ast.Walk(r, t)
+		}
+	}
+}
+
+func (r *resolver) push(s *scope) {
+	r.scope = s
+}
+
+func (r *resolver) pop() {
+	r.scope = r.scope.outer
+}
+
+func (r *resolver) resolve(id *ast.Identifier) {
+	// Captures are set by whichever scope performs the match, which
+	// is only known at runtime.
+	if len(id.Value) > 1 && id.Value[0] == '$' {
+		return
+	}
+
+	depth := 0
+	for s := r.scope; s != nil; s = s.outer {
+		if s.names[id.Value] {
+			id.Resolved, id.Depth = true, depth
+			return
+		}
+		if s.dynamic {
+			return
+		}
+		depth++
+	}
+
+	if _, ok := builtins[id.Value]; ok {
+		return
+	}
+	if _, ok := r.env.Get(id.Value); ok {
+		return
+	}
+	r.errors = append(r.errors, fmt.Sprintf("undefined variable %s around line %d, column %d",
+		id.Value, id.Token.Line, id.Token.Column))
+}
+
+// captureNames lists the regexp captures an IF scope holds.
+func captureNames() []string {
+	var names []string
+	for i := 1; i < 32; i++ {
+		names = append(names, fmt.Sprintf("$%d", i))
+	}
+	return names
+}
+
+func foreachNames(fe *ast.ForeachStatement) []string {
+	names := []string{fe.Ident}
+	if fe.Pattern != nil {
+		names = patternNames(fe.Pattern)
+	}
+	if fe.Index != "" {
+		names = append(names, fe.Index)
+	}
+	return names
+}
+
+func patternNames(pattern ast.Expression) []string {
+	var names []string
+	for _, id := range ast.PatternNames(pattern) {
+		names = append(names, id.Value)
+	}
+	return names
+}
+
+// countedForNames lists the variable a FOR loop declares with LET, which
+// lives in a temporary scope of its own.
+func countedForNames(cfe *ast.CountedForExpression) []string {
+	if let, ok := cfe.Init.(*ast.LetStatement); ok {
+		return []string{let.Name.Value}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package evaluator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"scream/ast"
+	"scream/lexer"
+	"scream/object"
+	"scream/parser"
+)
+
+func resolveScript(t *testing.T, input string) (*ast.Program, []string) {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parse errors: %s", strings.Join(p.Errors(), "; "))
+	}
+	return program, Resolve(program, object.NewEnvironment())
+}
+
+// depths lists the depth each identifier called name was resolved to,
+// in source order, or -1 for those left unresolved, such as the names
+// declared by LET and parameters.
+func depths(program *ast.Program, name string) []int {
+	var found []int
+	ast.Inspect(program, func(node ast.Node) bool {
+		if id, ok := node.(*ast.Identifier); ok && id.Value == name {
+			if id.Resolved {
+				found = append(found, id.Depth)
+			} else {
+				found = append(found, -1)
+			}
+		}
+		return true
+	})
+	return found
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		depths   []int
+		expected string
+	}{
+		// The parameter shadows the global within the function.
+		{`LET x = 1; FUNC f(x) { x * 10 } PRINT(f(2), x);`, "x", []int{-1, -1, 0, 0}, "201"},
+		// Closures reach the scope of the function which made them.
+		{`FUNC mk() { LET n = 5; FN() { n } } PRINT(mk()());`, "n", []int{-1, 1}, "5"},
+		// Defaults see the parameters before them.
+		{`FUNC f(a, b = a + 1) { b } PRINT(f(1));`, "a", []int{-1, 0}, "2"},
+		// foreach variables live in a scope of their own; other names
+		// assigned in the body belong to the enclosing one.
+		{`LET t = 0; foreach v in [1, 2] { LET t = v; } PRINT(t);`, "t", []int{-1, -1, 0}, "2"},
+		{`foreach i, v in ["a"] { PRINT(i, v); }`, "i", []int{0}, "0a"},
+		{`LET n = 0; FOR (LET i = 0; i < 3; i++) { n += i; } PRINT(n);`, "i", []int{-1, 0, 0}, "3"},
+		// Captures are set at runtime, by whichever scope matches.
+		{`IF ("ab" ~= /a(b)/) { PRINT($1); }`, "$1", []int{-1}, "b"},
+		// eval may declare names in the scope calling it, so those
+		// passing through it are found at runtime, but other scopes
+		// are resolved as usual.
+		{`LET x = 1; FUNC f() { eval("LET x = 2;"); x } PRINT(f(), x);`, "x", []int{-1, -1, 0}, "21"},
+		{`FUNC f() { eval("1"); LET n = 1; FN() { n } } FUNC g() { LET n = 2; n } PRINT(f()(), g());`,
+			"n", []int{-1, 1, -1, 0}, "12"},
+	}
+
+	for _, tt := range tests {
+		program, errs := resolveScript(t, tt.input)
+		if len(errs) != 0 {
+			t.Errorf("unexpected errors %q for %s", errs, tt.input)
+			continue
+		}
+		if got := depths(program, tt.name); !reflect.DeepEqual(got, tt.depths) {
+			t.Errorf("got depths %v for %s, want %v in %s", got, tt.name, tt.depths, tt.input)
+		}
+		if got := runProgram(t, program); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+func TestResolveUndefined(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`LET a = b;`, []string{"undefined variable b around line 1, column 9"}},
+		{`FUNC f() { y }
+PRINT(f(), z);`, []string{"undefined variable y around line 1, column 12", "undefined variable z around line 2, column 12"}},
+		{`FOR (LET i = 0; i < 3; i++) { } PRINT(i);`, []string{"undefined variable i around line 1, column 39"}},
+		{`foreach v in [1] { } PRINT(v);`, []string{"undefined variable v around line 1, column 28"}},
+		{`PRINT(later); LET later = 1;`, nil},
+		{`eval("LET q = 1;"); PRINT(q);`, nil},
+		{`FUNC f() { eval("LET q = 1;"); FN() { q } } PRINT(f()());`, nil},
+		{`FUNC f() { eval("LET q = 1;"); q } PRINT(f(), q);`, []string{"undefined variable q around line 1, column 47"}},
+		{`FUNC f() { import("lib.scream"); helper() }`, nil},
+	}
+
+	for _, tt := range tests {
+		if _, errs := resolveScript(t, tt.input); !reflect.DeepEqual(errs, tt.expected) {
+			t.Errorf("got %q, want %q for %s", errs, tt.expected, tt.input)
+		}
+	}
+}
@@ -59,17 +59,19 @@ func evalFun(env *object.Environment, args ...object.Object) object.Object {
 			return (Eval(program, env))
 		}
 
-		fmt.Printf("Error parsing eval-string: %s", txt)
-		for _, msg := range p.Errors() {
-			fmt.Printf("\t%s\n", msg)
+		var msg strings.Builder
+		fmt.Fprintf(&msg, "Error parsing eval-string: %s", txt)
+		for _, e := range p.Errors() {
+			fmt.Fprintf(&msg, "\t%s\n", e)
 		}
-		os.Exit(1)
+		env.Streams().Print(msg.String())
+		exit(env, 1)
 	}
 	return newError("argument to `eval` not supported, got=%s",
 		args[0].Type())
 }
 
-func exitFun(args ...object.Object) object.Object {
+func exitFun(env *object.Environment, args ...object.Object) object.Object {
 
 	code := 0
 
@@ -82,7 +84,7 @@ func exitFun(args ...object.Object) object.Object {
 		}
 	}
 
-	os.Exit(code)
+	exit(env, code)
 	return NULL
 }
 
@@ -131,6 +133,8 @@ func lenFun(args ...object.Object) object.Object {
 		return &object.Integer{Value: 0}
 	case *object.Array:
 		return &object.Integer{Value: int64(len(arg.Elements))}
+	case *object.Range:
+		return &object.Integer{Value: arg.Len()}
 	default:
 		return newError("argument to `len` not supported, got=%s",
 			args[0].Type())
@@ -204,7 +208,7 @@ func mkdirFun(args ...object.Object) object.Object {
 
 }
 
-func openFun(args ...object.Object) object.Object {
+func openFun(env *object.Environment, args ...object.Object) object.Object {
 
 	path := ""
 	mode := "r"
@@ -233,12 +237,15 @@ func openFun(args ...object.Object) object.Object {
 		}
 	}
 
-	file := &object.File{Filename: path}
+	file := &object.File{Filename: path, Streams: env.Streams()}
+	if OnLoad != nil && mode == "r" && !strings.HasPrefix(path, "!") {
+		OnLoad(path)
+	}
 	file.Open(mode)
 	return (file)
 }
 
-func pragmaFun(args ...object.Object) object.Object {
+func pragmaFun(env *object.Environment, args ...object.Object) object.Object {
 
 	if len(args) > 1 {
 		return newError("wrong number of arguments. got=%d, want=0|1",
@@ -251,11 +258,25 @@ func pragmaFun(args ...object.Object) object.Object {
 			input := args[0].(*object.String).Value
 			input = strings.ToLower(input)
 
-			if strings.HasPrefix(input, "no-") {
-				real := strings.TrimPrefix(input, "no-")
-				delete(PRAGMAS, real)
-			} else {
-				PRAGMAS[input] = 1
+			real := strings.TrimPrefix(input, "no-")
+			enabled := real == input
+
+			vetoed := false
+			for _, h := range env.Hooks() {
+				if h.BeforePragma(real, enabled) != nil {
+					vetoed = true
+					break
+				}
+			}
+
+			if !vetoed {
+				pragmaMu.Lock()
+				if enabled {
+					PRAGMAS[real] = 1
+				} else {
+					delete(PRAGMAS, real)
+				}
+				pragmaMu.Unlock()
 			}
 		default:
 			return newError("argument to `pragma` not supported, got=%s",
@@ -263,6 +284,8 @@ func pragmaFun(args ...object.Object) object.Object {
 		}
 	}
 
+	pragmaMu.RLock()
+	defer pragmaMu.RUnlock()
 	len := len(PRAGMAS)
 
 	array := make([]object.Object, len)
@@ -281,6 +304,9 @@ func pushFun(args ...object.Object) object.Object {
 		return newError("wrong number of arguments. got=%d, want=1",
 			len(args))
 	}
+	if r, ok := args[0].(*object.Range); ok {
+		args[0] = r.ToArray()
+	}
 	if args[0].Type() != object.ARRAY_OBJ {
 		return newError("argument to `push` must be ARRAY, got=%s",
 			args[0].Type())
@@ -293,25 +319,35 @@ func pushFun(args ...object.Object) object.Object {
 	return &object.Array{Elements: newElements}
 }
 
-func putsFun(args ...object.Object) object.Object {
+func putsFun(env *object.Environment, args ...object.Object) object.Object {
 	for _, arg := range args {
-		fmt.Print(arg.Inspect())
+		writeOutput(env, arg.Inspect())
 	}
 	return NULL
 }
 
-func printfFun(args ...object.Object) object.Object {
+func printfFun(env *object.Environment, args ...object.Object) object.Object {
 
 	out := sprintfFun(args...)
 
 	if out.Type() == object.STRING_OBJ {
-		fmt.Print(out.(*object.String).Value)
+		writeOutput(env, out.(*object.String).Value)
 
 	}
 
 	return NULL
 }
 
+// writeOutput writes text to the output stream unless a hook vetoes it.
+func writeOutput(env *object.Environment, text string) {
+	for _, h := range env.Hooks() {
+		if h.BeforePrint(text) != nil {
+			return
+		}
+	}
+	env.Streams().Print(text)
+}
+
 func sprintfFun(args ...object.Object) object.Object {
 
 	if len(args) < 1 {
@@ -426,7 +462,7 @@ func hashDelete(args ...object.Object) object.Object {
 
 	hash := args[0].(*object.Hash)
 
-	key, ok := args[1].(object.Hashable)
+	key, ok := object.AsHashable(args[1])
 	if !ok {
 		return newError("key `delete` into HASH must be Hashable, got=%s",
 			args[1].Type())
@@ -451,7 +487,7 @@ func setFun(args ...object.Object) object.Object {
 		return newError("argument to `set` must be HASH, got=%s",
 			args[0].Type())
 	}
-	key, ok := args[1].(object.Hashable)
+	key, ok := object.AsHashable(args[1])
 	if !ok {
 		return newError("key `set` into HASH must be Hashable, got=%s",
 			args[1].Type())
@@ -495,6 +531,25 @@ func typeFun(args ...object.Object) object.Object {
 		return &object.String{Value: "file"}
 	case *object.Array:
 		return &object.String{Value: "array"}
+	case *object.Range:
+		// Ranges stand in for the arrays .. used to make.
+		return &object.String{Value: "array"}
+	case *object.Generator:
+		return &object.String{Value: "generator"}
+	case *object.Task:
+		return &object.String{Value: "task"}
+	case *object.Channel:
+		return &object.String{Value: "channel"}
+	case *object.Mutex:
+		return &object.String{Value: "mutex"}
+	case *object.WaitGroup:
+		return &object.String{Value: "waitgroup"}
+	case *object.Atomic:
+		return &object.String{Value: "atomic"}
+	case *object.Pool:
+		return &object.String{Value: "pool"}
+	case *object.Error:
+		return &object.String{Value: "error"}
 	case *object.Function:
 		return &object.String{Value: "function"}
 	case *object.Integer:
@@ -503,6 +558,8 @@ func typeFun(args ...object.Object) object.Object {
 		return &object.String{Value: "float"}
 	case *object.Hash:
 		return &object.String{Value: "hash"}
+	case *object.HostObject:
+		return &object.String{Value: args[0].(*object.HostObject).HostType.Name}
 	default:
 		return newError("argument to `type` not supported, got=%s",
 			args[0].Type())
@@ -539,7 +596,7 @@ func init() {
 		})
 	RegisterBuiltin("exit",
 		func(env *object.Environment, args ...object.Object) object.Object {
-			return (exitFun(args...))
+			return (exitFun(env, args...))
 		})
 	RegisterBuiltin("int",
 		func(env *object.Environment, args ...object.Object) object.Object {
@@ -563,11 +620,11 @@ func init() {
 		})
 	RegisterBuiltin("pragma",
 		func(env *object.Environment, args ...object.Object) object.Object {
-			return (pragmaFun(args...))
+			return (pragmaFun(env, args...))
 		})
 	RegisterBuiltin("open",
 		func(env *object.Environment, args ...object.Object) object.Object {
-			return (openFun(args...))
+			return (openFun(env, args...))
 		})
 	RegisterBuiltin("APPEND",
 		func(env *object.Environment, args ...object.Object) object.Object {
@@ -575,11 +632,11 @@ func init() {
 		})
 	RegisterBuiltin("PRINT",
 		func(env *object.Environment, args ...object.Object) object.Object {
-			return (putsFun(args...))
+			return (putsFun(env, args...))
 		})
 	RegisterBuiltin("printf",
 		func(env *object.Environment, args ...object.Object) object.Object {
-			return (printfFun(args...))
+			return (printfFun(env, args...))
 		})
 	RegisterBuiltin("set",
 		func(env *object.Environment, args ...object.Object) object.Object {
@@ -0,0 +1,113 @@
+package evaluator
+
+import (
+	"scream/object"
+)
+
+// errorFun returns an error value, which is only thrown if it is given
+// to throw.
+func errorFun(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return newError("wrong number of arguments. got=%d, want=1-3",
+			len(args))
+	}
+	msg, ok := args[0].(*object.String)
+	if !ok {
+		return newError("argument to `error` must be STRING, got=%s",
+			args[0].Type())
+	}
+	err := &object.Error{Message: msg.Value, Held: true}
+	if len(args) > 1 {
+		kind, ok := args[1].(*object.String)
+		if !ok {
+			return newError("kind given to `error` must be STRING, got=%s",
+				args[1].Type())
+		}
+		err.Kind = kind.Value
+	}
+	if len(args) > 2 {
+		cause, ok := args[2].(*object.Error)
+		if !ok {
+			return newError("cause given to `error` must be ERROR, got=%s",
+				args[2].Type())
+		}
+		err.Cause = cause
+	}
+	return err
+}
+
+func isErrorFun(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	_, ok := args[0].(*object.Error)
+	return &object.Boolean{Value: ok}
+}
+
+// throwFun throws an error value, or a new error with the given message
+// and kind.
+func throwFun(args ...object.Object) object.Object {
+	if len(args) == 1 {
+		if err, ok := args[0].(*object.Error); ok {
+			return err.Thrown()
+		}
+	}
+	err := errorFun(args...)
+	if isError(err) {
+		return err
+	}
+	return err.(*object.Error).Thrown()
+}
+
+// tryFun calls a function, returning any error it throws as a value.
+func tryFun(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError("wrong number of arguments. got=%d, want=1+",
+			len(args))
+	}
+	res := applyFunction(env, args[0], args[1:], nil)
+	if isError(res) {
+		return res.(*object.Error).AsValue()
+	}
+	return res
+}
+
+func unwrapFun(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	err, ok := args[0].(*object.Error)
+	if !ok {
+		return newError("argument to `unwrap` must be ERROR, got=%s",
+			args[0].Type())
+	}
+	if err.Cause == nil {
+		return NULL
+	}
+	return err.Cause.AsValue()
+}
+
+func init() {
+	RegisterBuiltin("error",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (errorFun(args...))
+		})
+	RegisterBuiltin("is_error",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (isErrorFun(args...))
+		})
+	RegisterBuiltin("throw",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (throwFun(args...))
+		})
+	RegisterBuiltin("try",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (tryFun(env, args...))
+		})
+	RegisterBuiltin("unwrap",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (unwrapFun(args...))
+		})
+}
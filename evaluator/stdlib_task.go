@@ -0,0 +1,160 @@
+package evaluator
+
+import (
+	"reflect"
+	"time"
+
+	"scream/object"
+)
+
+func spawnFun(env *object.Environment, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError("wrong number of arguments. got=%d, want=1+",
+			len(args))
+	}
+	fn := args[0]
+	switch fn.(type) {
+	case *object.Function, *object.Builtin:
+	default:
+		return newError("argument to `spawn` must be FUNCTION, got=%s",
+			fn.Type())
+	}
+	rest := args[1:]
+	return object.NewTask(func() object.Object {
+		return applyFunction(env, fn, rest, nil)
+	})
+}
+
+func channelFun(args ...object.Object) object.Object {
+	size := int64(0)
+	switch len(args) {
+	case 0:
+	case 1:
+		n, ok := args[0].(*object.Integer)
+		if !ok || n.Value < 0 {
+			return newError("argument to `channel` must be a non-negative INTEGER, got=%s",
+				args[0].Inspect())
+		}
+		size = n.Value
+	default:
+		return newError("wrong number of arguments. got=%d, want=0|1",
+			len(args))
+	}
+	return object.NewChannel(int(size))
+}
+
+// selectFun waits for the first of its channels to receive a value,
+// returning the channel's position among the arguments along with the
+// value, or NULL if the channel was closed.  A final INTEGER argument
+// gives up after that many milliseconds, returning [-1, NULL].
+func selectFun(args ...object.Object) object.Object {
+	var cases []reflect.SelectCase
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case *object.Channel:
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(arg.C)})
+		case *object.Integer:
+			if i != len(args)-1 {
+				return newError("timeout given to `select` must be its last argument")
+			}
+			timeout := time.After(time.Duration(arg.Value) * time.Millisecond)
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(timeout)})
+		default:
+			return newError("argument to `select` must be CHANNEL, got=%s",
+				arg.Type())
+		}
+	}
+	if len(cases) == 0 {
+		return newError("wrong number of arguments. got=0, want=1+")
+	}
+
+	chosen, val, ok := reflect.Select(cases)
+	if _, timedOut := args[chosen].(*object.Integer); timedOut {
+		return &object.Array{Elements: []object.Object{&object.Integer{Value: -1}, NULL}}
+	}
+	var received object.Object = NULL
+	if ok {
+		received = val.Interface().(object.Object)
+	}
+	return &object.Array{Elements: []object.Object{&object.Integer{Value: int64(chosen)}, received}}
+}
+
+func mutexFun(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0",
+			len(args))
+	}
+	return object.NewMutex()
+}
+
+func waitgroupFun(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("wrong number of arguments. got=%d, want=0",
+			len(args))
+	}
+	return &object.WaitGroup{}
+}
+
+func atomicFun(args ...object.Object) object.Object {
+	value := int64(0)
+	switch len(args) {
+	case 0:
+	case 1:
+		n, ok := args[0].(*object.Integer)
+		if !ok {
+			return newError("argument to `atomic` must be INTEGER, got=%s",
+				args[0].Type())
+		}
+		value = n.Value
+	default:
+		return newError("wrong number of arguments. got=%d, want=0|1",
+			len(args))
+	}
+	return object.NewAtomic(value)
+}
+
+func poolFun(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("wrong number of arguments. got=%d, want=1",
+			len(args))
+	}
+	n, ok := args[0].(*object.Integer)
+	if !ok || n.Value < 1 {
+		return newError("argument to `pool` must be a positive INTEGER, got=%s",
+			args[0].Inspect())
+	}
+	return object.NewPool(int(n.Value))
+}
+
+func init() {
+	object.Apply = Call
+
+	RegisterBuiltin("spawn",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (spawnFun(env, args...))
+		})
+	RegisterBuiltin("channel",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (channelFun(args...))
+		})
+	RegisterBuiltin("select",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (selectFun(args...))
+		})
+	RegisterBuiltin("mutex",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (mutexFun(args...))
+		})
+	RegisterBuiltin("waitgroup",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (waitgroupFun(args...))
+		})
+	RegisterBuiltin("atomic",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (atomicFun(args...))
+		})
+	RegisterBuiltin("pool",
+		func(env *object.Environment, args ...object.Object) object.Object {
+			return (poolFun(args...))
+		})
+}
@@ -0,0 +1,72 @@
+package evaluator
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"scream/lexer"
+	"scream/object"
+	"scream/parser"
+)
+
+// TestStreams checks that a script given its own streams reads and
+// writes only through them, never through the process's stdio.
+func TestStreams(t *testing.T) {
+	// Anything reaching the default streams or the real files fails
+	// the test.
+	var stray bytes.Buffer
+	saved := object.Stdio
+	object.Stdio = object.NewStreams(strings.NewReader("stray input\n"), &stray, &stray)
+	defer func() { object.Stdio = saved }()
+
+	for _, f := range []**os.File{&os.Stdout, &os.Stderr} {
+		tmp, err := ioutil.TempFile(t.TempDir(), "stdio")
+		if err != nil {
+			t.Fatal(err)
+		}
+		real := *f
+		*f = tmp
+		defer func(f **os.File, tmp *os.File) {
+			*f = real
+			if data, _ := ioutil.ReadFile(tmp.Name()); len(data) != 0 {
+				t.Errorf("wrote %q to the process's stdio", data)
+			}
+			tmp.Close()
+		}(f, tmp)
+	}
+
+	input := `LET input = open("!STDIN!");
+LET name = input.read();
+PRINT("hello ", name);
+printf("%d lines left\n", LEN(input.lines()));
+LET out = open("!STDOUT!", "w");
+out.write("via file\n");
+LET err = open("!STDERR!", "w");
+err.write("to stderr\n");
+PRINT(missing);
+`
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parse errors: %s", strings.Join(p.Errors(), "; "))
+	}
+
+	var out, errs bytes.Buffer
+	env := object.NewEnvironment()
+	env.SetStreams(object.NewStreams(strings.NewReader("bob\nline 2\nline 3\n"), &out, &errs))
+	Eval(program, env)
+	env.Streams().Flush()
+
+	if got, want := out.String(), "hello bob\n2 lines left\nvia file\n"; !strings.HasPrefix(got, want) {
+		t.Errorf("stdout is %q, want it to start %q", got, want)
+	}
+	if got := errs.String(); !strings.Contains(got, "to stderr\n") || !strings.Contains(got, "identifier not found: missing") {
+		t.Errorf("stderr is %q", got)
+	}
+	if stray.Len() != 0 {
+		t.Errorf("wrote %q to object.Stdio", stray.String())
+	}
+}
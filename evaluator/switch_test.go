@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"testing"
+
+	"scream/lexer"
+	"scream/parser"
+)
+
+func TestSwitchPatterns(t *testing.T) {
+	classify := `FUNC classify(v) {
+  switch (v) {
+    case 0 { "zero" }
+    case 1..9 { "digit" }
+    case [A, B] { A + B }
+    case [1, ...REST] { REST }
+    case {name, age = 0} if age >= 18 { "adult " + name }
+    case {name} { "minor " + name }
+    case string, float { "scalar" }
+    case null { "nothing" }
+    case N if N > 100 { "big" }
+    case integer { "int" }
+  }
+}
+`
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`PRINT(classify(0), classify(5), classify(500), classify(42));`, "zerodigitbigint"},
+		{`PRINT(classify([2, 3]), classify([1, 2, 3]));`, "5[2, 3]"},
+		{`PRINT(classify({"name": "ann", "age": 30}), classify({"name": "bob"}));`, "adult annminor bob"},
+		{`PRINT(classify("x"), classify(1.5), classify(NIL));`, "scalarscalarnothing"},
+		{`LET X = switch (3) { case 4 { "no" } }; PRINT(X);`, "null"},
+		{`LET X = switch (3) { case 4 { "no" } default { "yes" } }; PRINT(X);`, "yes"},
+		{`LET X = switch ("abc") { case /b(c)/ { $1 } }; PRINT(X);`, "c"},
+		{`LET X = switch ([1]) { case [A], [A, B] if A > 0 { A } }; PRINT(X);`, "1"},
+		{`LET X = switch ([5, 6]) { case [A] { "one" } case [A, B] { A * B } }; PRINT(X);`, "30"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, classify+tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+// TestSwitchScopes checks that only the names a case binds are kept to
+// it, both when the script has been resolved and when it has not.
+func TestSwitchScopes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`switch (1) { case 1 { LET Z = 5; } } PRINT(Z);`, "5"},
+		{`switch (1) { case 2 { LET Z = 5; } default { LET Z = 6; } } PRINT(Z);`, "6"},
+		{`switch ([1]) { case [A] { LET Z = A; } } PRINT(Z);`, "1"},
+		{`LET Z = 0; switch (2) { case N if N > 1 { LET Z = N; } } PRINT(Z);`, "2"},
+		{`LET A = 0; switch ([7]) { case [A] { PRINT(A); } } PRINT(A);`, "70"},
+		{`FUNC f(v) { switch (v) { case 1, [B] { LET Z = "hit"; } } Z } PRINT(f(1), f([2]));`, "hithit"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+		program, errs := resolveScript(t, tt.input)
+		if len(errs) != 0 {
+			t.Errorf("unexpected errors %q for %s", errs, tt.input)
+			continue
+		}
+		if got := runProgram(t, program); got != tt.expected {
+			t.Errorf("got %q, want %q once resolved for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		input    string
+		warnings int
+	}{
+		{`switch (1) { case 1 { 2 } }`, 1},
+		{`switch (1) { case N if N > 1 { 2 } }`, 1},
+		{`switch (1) { case 1 { 2 } default { 3 } }`, 0},
+		{`FUNC f(x) { switch (x) { case 1 { 2 } } } switch (1) { default { 3 } }`, 1},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if got := Lint(program); len(got) != tt.warnings {
+			t.Errorf("got %q, want %d warnings for %s", got, tt.warnings, tt.input)
+		}
+	}
+}
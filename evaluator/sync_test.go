@@ -0,0 +1,176 @@
+package evaluator
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"scream/lexer"
+	"scream/object"
+	"scream/parser"
+)
+
+func TestSyncPrimitives(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`
+LET m = mutex();
+LET wg = waitgroup();
+LET inside = atomic();
+LET clashes = atomic();
+FOR (LET i = 0; i < 20; i++) {
+  wg.add();
+  spawn(FN() {
+    m.with(FN() {
+      IF (inside.inc() != 1) { clashes.inc(); }
+      inside.dec();
+    });
+    wg.done();
+  });
+}
+wg.wait();
+PRINT(clashes.get(), " ", inside.get());
+`, "0 0"},
+		{`
+LET c = atomic(10);
+LET wg = waitgroup();
+wg.add(8);
+FOR (LET i = 0; i < 8; i++) {
+  spawn(FN() { c.add(5); c.dec(); wg.done(); });
+}
+wg.wait();
+PRINT(c.get(), " ", c.swap(42, 0), " ", c.swap(0, 1), " ", c);
+`, "42 true true 1"},
+		{`
+LET busy = atomic();
+LET most = atomic();
+LET p = pool(2);
+LET squares = p.map(FN(x) {
+  LET n = busy.inc();
+  IF (n > most.get()) { most.set(n); }
+  busy.dec();
+  x * x;
+}, 1..8);
+PRINT(squares, " ", most.get() <= 2);
+`, "[1, 4, 9, 16, 25, 36, 49, 64] true"},
+		{`
+LET xs = [1, 2, 3, 4, 5, 6, 7, 8];
+LET p = pool(4);
+LET wg = waitgroup();
+LET sum = atomic();
+wg.add(8);
+FOR (LET i = 0; i < 8; i++) {
+  spawn(FN() {
+    foreach v in p.map(FN(x) { x + 1 }, xs) { sum.add(v); }
+    wg.done();
+  });
+}
+wg.wait();
+PRINT(sum.get(), " ", pool(2).map(FN(x) { pool(2).map(FN(y) { x * y }, [1, 2]) }, [1, 2]));
+`, "352 [[1, 2], [2, 4]]"},
+		{`PRINT(type(mutex()), type(waitgroup()), type(atomic()), type(pool(1)));`,
+			"mutexwaitgroupatomicpool"},
+	}
+
+	for _, tt := range tests {
+		if got := runScript(t, tt.input); got != tt.expected {
+			t.Errorf("got %q, want %q for %s", got, tt.expected, tt.input)
+		}
+	}
+}
+
+func TestSyncMisuse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`LET m = mutex(); m.lock(); m.unlock(); m.unlock();`, "unlock of unlocked mutex"},
+		{`waitgroup().done();`, "negative WaitGroup counter"},
+		{`pool(0);`, "must be a positive INTEGER"},
+	}
+
+	for _, tt := range tests {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		env := object.NewEnvironment()
+		env.SetStreams(object.NewStreams(strings.NewReader(""), io.Discard, io.Discard))
+		res := Eval(program, env)
+		if !isError(res) || !strings.Contains(res.Inspect(), tt.expected) {
+			t.Errorf("got %v, want an error mentioning %q for %s", res, tt.expected, tt.input)
+		}
+	}
+}
+
+// TestTasksShareState runs many tasks which write to the shared streams,
+// report errors, trace their calls and import the same file, all at
+// once.  It is only meaningful under the race detector.
+func TestTasksShareState(t *testing.T) {
+	lib := filepath.Join(t.TempDir(), "lib.scream")
+	if err := ioutil.WriteFile(lib, []byte(`PRINT("loaded;");`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var trace bytes.Buffer
+	TRACE.Out = &trace
+	PRAGMAS["trace"] = 1
+	defer func() {
+		TRACE.Out = nil
+		Reset()
+	}()
+
+	input := `
+LET out = open("!STDOUT!", "w");
+LET err = open("!STDERR!", "w");
+FUNC inner(n) { n * 2 }
+FUNC work(n) {
+  LET sum = 0;
+  FOR (LET j = 0; j < 5; j++) {
+    import("` + lib + `");
+    try(FN() { 1 + "a" });
+    try(FN() { LET s = 1; s += "a"; });
+    out.write("w;");
+    err.write("e;");
+    PRINT("p;");
+    sum = sum + inner(n);
+  }
+  sum
+}
+LET start = channel();
+LET tasks = [];
+FOR (LET i = 0; i < 20; i++) {
+  tasks = APPEND(tasks, spawn(FN(n) { start.recv(); work(n) }, i));
+}
+start.close();
+LET total = 0;
+foreach task in tasks { total = total + task.wait(); }
+PRINT("total=", total);
+`
+	out := runScript(t, input)
+	for _, want := range []struct {
+		text  string
+		count int
+	}{{"loaded;", 1}, {"Error: ", 100}, {"Error handling += ", 100}, {"w;", 100}, {"p;", 100}, {"total=1900", 1}} {
+		if got := strings.Count(out, want.text); got != want.count {
+			t.Errorf("got %d of %q, want %d in %q", got, want.text, want.count, out)
+		}
+	}
+
+	// Each task has its own calls, so inner is always traced at the
+	// same depth however the tasks interleave.
+	calls := 0
+	for _, line := range strings.Split(trace.String(), "\n") {
+		if strings.Contains(line, "-> inner(") {
+			calls++
+			if !strings.HasPrefix(line, "    -> inner(") || strings.HasPrefix(line, "     ") {
+				t.Errorf("traced at the wrong depth: %q", line)
+			}
+		}
+	}
+	if calls != 100 {
+		t.Errorf("traced %d calls of inner, want 100", calls)
+	}
+}
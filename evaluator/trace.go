@@ -0,0 +1,152 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"scream/ast"
+	"scream/object"
+)
+
+// Tracer logs evaluated statements, function calls and variable
+// assignments while the "trace" pragma is enabled.
+type Tracer struct {
+	// Out receives the trace; when nil it goes to the error stream of
+	// object.Stdio.
+	Out io.Writer
+
+	// JSON selects one JSON object per line instead of plain text.
+	JSON bool
+
+	// Filter, when set, restricts the trace to calls of the named
+	// function and everything they call in turn.
+	Filter string
+
+	// mu serialises events from tasks running concurrently.  The calls
+	// leading to each event are kept by the environment it happened in,
+	// so every task has its own.
+	mu sync.Mutex
+}
+
+type traceEvent struct {
+	Event  string   `json:"event"`
+	Depth  int      `json:"depth"`
+	Line   int      `json:"line,omitempty"`
+	Column int      `json:"column,omitempty"`
+	Name   string   `json:"name,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Args   []string `json:"args,omitempty"`
+	Value  string   `json:"value,omitempty"`
+}
+
+var TRACE = &Tracer{}
+
+func traceEnabled() bool {
+	return pragmaEnabled("trace")
+}
+
+func (t *Tracer) active(frames []string) bool {
+	if t.Filter == "" {
+		return true
+	}
+	for _, name := range frames {
+		if name == t.Filter {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tracer) emit(frames []string, ev traceEvent) {
+	if !t.active(frames) {
+		return
+	}
+	ev.Depth = len(frames)
+
+	var line string
+	if t.JSON {
+		out, err := json.Marshal(ev)
+		if err != nil {
+			return
+		}
+		line = string(out) + "\n"
+	} else {
+		indent := strings.Repeat("  ", ev.Depth)
+		switch ev.Event {
+		case "statement":
+			line = fmt.Sprintf("%s%d:%d %s\n", indent, ev.Line, ev.Column, ev.Text)
+		case "call":
+			line = fmt.Sprintf("%s-> %s(%s)\n", indent, ev.Name, strings.Join(ev.Args, ", "))
+		case "return":
+			line = fmt.Sprintf("%s<- %s = %s\n", indent, ev.Name, ev.Value)
+		case "set":
+			line = fmt.Sprintf("%sset %s = %s\n", indent, ev.Name, ev.Value)
+		}
+	}
+
+	if t.Out != nil {
+		io.WriteString(t.Out, line)
+	} else {
+		object.Stdio.Diagnose(line)
+	}
+}
+
+func (t *Tracer) statement(env *object.Environment, stmt ast.Statement) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tok := ast.TokenOf(stmt)
+	t.emit(env.Frames(), traceEvent{Event: "statement", Line: tok.Line, Column: tok.Column, Text: traceText(stmt.String())})
+}
+
+// call traces a call made from env, returning the calls which lead to
+// the body of the function called.
+func (t *Tracer) call(env *object.Environment, name string, args []object.Object) []string {
+	outer := env.Frames()
+	frames := append(outer[:len(outer):len(outer)], name)
+
+	ev := traceEvent{Event: "call", Name: name, Args: []string{}}
+	for _, arg := range args {
+		ev.Args = append(ev.Args, traceValue(arg))
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.emit(frames, ev)
+	return frames
+}
+
+func (t *Tracer) ret(frames []string, name string, result object.Object) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.emit(frames, traceEvent{Event: "return", Name: name, Value: traceValue(result)})
+}
+
+func (t *Tracer) set(env *object.Environment, name string, val object.Object) {
+	if traceEnabled() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.emit(env.Frames(), traceEvent{Event: "set", Name: name, Value: traceValue(val)})
+	}
+}
+
+func traceValue(obj object.Object) string {
+	if obj == nil {
+		return "null"
+	}
+	return traceText(obj.Inspect())
+}
+
+// traceText folds a statement or value onto a single, bounded line.
+func traceText(txt string) string {
+	txt = strings.Join(strings.Fields(txt), " ")
+	if len(txt) > 72 {
+		txt = txt[:69] + "..."
+	}
+	return txt
+}
+
+func init() {
+	object.OnSet = TRACE.set
+}
@@ -0,0 +1,87 @@
+package evaluator
+
+import (
+	"bytes"
+	"testing"
+)
+
+func traceScript(t *testing.T, asJSON bool, filter string, input string) string {
+	t.Helper()
+	var out bytes.Buffer
+	TRACE.Out, TRACE.JSON, TRACE.Filter = &out, asJSON, filter
+	PRAGMAS["trace"] = 1
+	defer func() {
+		delete(PRAGMAS, "trace")
+		TRACE.Out, TRACE.JSON, TRACE.Filter = nil, false, ""
+	}()
+	runScript(t, input)
+	return out.String()
+}
+
+func TestTrace(t *testing.T) {
+	input := `FUNC double(x) { LET y = x * 2; RETURN y; }
+FUNC twice(x) { double(double(x)) }
+LET z = twice(3);
+`
+	tests := []struct {
+		asJSON   bool
+		filter   string
+		expected string
+	}{
+		{false, "", `1:1 double(x) LET y = (x * 2);RETURN y;
+set double = fn(x) { LET y = (x * 2);RETURN y; }
+2:1 twice(x) double(double(x))
+set twice = fn(x) { double(double(x)) }
+3:1 LET z = twice(3);
+  -> twice(3)
+  set x = 3
+  2:17 double(double(x))
+    -> double(3)
+    set x = 3
+    1:18 LET y = (x * 2);
+    set y = 6
+    1:33 RETURN y;
+    <- double = 6
+    -> double(6)
+    set x = 6
+    1:18 LET y = (x * 2);
+    set y = 12
+    1:33 RETURN y;
+    <- double = 12
+  <- twice = 12
+set z = 12
+`},
+		{false, "double", `    -> double(3)
+    set x = 3
+    1:18 LET y = (x * 2);
+    set y = 6
+    1:33 RETURN y;
+    <- double = 6
+    -> double(6)
+    set x = 6
+    1:18 LET y = (x * 2);
+    set y = 12
+    1:33 RETURN y;
+    <- double = 12
+`},
+		{true, "double", `{"event":"call","depth":2,"name":"double","args":["3"]}
+{"event":"set","depth":2,"name":"x","value":"3"}
+{"event":"statement","depth":2,"line":1,"column":18,"text":"LET y = (x * 2);"}
+{"event":"set","depth":2,"name":"y","value":"6"}
+{"event":"statement","depth":2,"line":1,"column":33,"text":"RETURN y;"}
+{"event":"return","depth":2,"name":"double","value":"6"}
+{"event":"call","depth":2,"name":"double","args":["6"]}
+{"event":"set","depth":2,"name":"x","value":"6"}
+{"event":"statement","depth":2,"line":1,"column":18,"text":"LET y = (x * 2);"}
+{"event":"set","depth":2,"name":"y","value":"12"}
+{"event":"statement","depth":2,"line":1,"column":33,"text":"RETURN y;"}
+{"event":"return","depth":2,"name":"double","value":"12"}
+`},
+	}
+
+	for _, tt := range tests {
+		if got := traceScript(t, tt.asJSON, tt.filter, input); got != tt.expected {
+			t.Errorf("got\n%s\nwant\n%s", got, tt.expected)
+		}
+	}
+}
@@ -18,10 +18,14 @@ type Lexer struct {
 	characters []rune
 
 	prevToken token.Token
+
+	line int
+
+	column int
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{characters: []rune(input)}
+	l := &Lexer{characters: []rune(input), line: 1}
 	l.readChar()
 	return l
 }
@@ -42,6 +46,11 @@ func (l *Lexer) GetLine() int {
 	return line
 }
 func (l *Lexer) readChar() {
+	if l.readPosition > 0 && l.position < len(l.characters) && l.characters[l.position] == rune('\n') {
+		l.line++
+		l.column = 0
+	}
+	l.column++
 	if l.readPosition >= len(l.characters) {
 		l.ch = rune(0)
 	} else {
@@ -64,6 +73,8 @@ func (l *Lexer) NextToken() token.Token {
 		l.skipMultiLineComment()
 	}
 
+	line, column := l.line, l.column
+
 	switch l.ch {
 	case rune('&'):
 		if l.peekChar() == rune('&') {
@@ -102,6 +113,10 @@ func (l *Lexer) NextToken() token.Token {
 			ch := l.ch
 			l.readChar()
 			tok = token.Token{Type: token.DOTDOT, Literal: string(ch) + string(l.ch)}
+			if l.peekChar() == rune('.') {
+				l.readChar()
+				tok = token.Token{Type: token.ELLIPSIS, Literal: tok.Literal + string(l.ch)}
+			}
 		} else {
 			tok = newToken(token.PERIOD, l.ch)
 		}
@@ -158,6 +173,12 @@ func (l *Lexer) NextToken() token.Token {
 					tok.Type = token.REGEXP
 					tok.Literal = str
 				}
+
+				// readRegexp leaves us on the character after the
+				// expression and its flags.
+				tok.Line, tok.Column = line, column
+				l.prevToken = tok
+				return tok
 			}
 		}
 	case rune('*'):
@@ -229,6 +250,7 @@ func (l *Lexer) NextToken() token.Token {
 
 		if isDigit(l.ch) {
 			tok = l.readDecimal()
+			tok.Line, tok.Column = line, column
 			l.prevToken = tok
 			return tok
 
@@ -236,10 +258,12 @@ func (l *Lexer) NextToken() token.Token {
 
 		tok.Literal = l.readIdentifier()
 		tok.Type = token.LookupIdentifier(tok.Literal)
+		tok.Line, tok.Column = line, column
 		l.prevToken = tok
 		return tok
 	}
 	l.readChar()
+	tok.Line, tok.Column = line, column
 	l.prevToken = tok
 	return tok
 }
@@ -248,42 +272,43 @@ func newToken(tokenType token.Type, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
-func (l *Lexer) readIdentifier() string {
-
-	valid := map[string]bool{
-		"directory.glob":     true,
-		"math.abs":           true,
-		"math.random":        true,
-		"math.sqrt":          true,
-		"os.environment":     true,
-		"os.getenv":          true,
-		"os.setenv":          true,
-		"string.interpolate": true,
-	}
-
-	types := []string{"string.",
-		"array.",
-		"integer.",
-		"float.",
-		"hash.",
-		"object."}
+// dottedIdentifiers are the names containing a period which are read
+// as a single identifier, along with any name starting with one of
+// methodPrefixes.
+var dottedIdentifiers = map[string]bool{
+	"directory.glob":     true,
+	"math.abs":           true,
+	"math.random":        true,
+	"math.sqrt":          true,
+	"os.environment":     true,
+	"os.getenv":          true,
+	"os.setenv":          true,
+	"string.interpolate": true,
+}
 
-	id := ""
+var methodPrefixes = []string{"string.",
+	"array.",
+	"integer.",
+	"float.",
+	"hash.",
+	"object."}
 
+func (l *Lexer) readIdentifier() string {
 	position := l.position
 	rposition := l.readPosition
+	line, column := l.line, l.column
 
 	for isIdentifier(l.ch) {
-		id += string(l.ch)
 		l.readChar()
 	}
+	id := string(l.characters[position:l.position])
 
 	if strings.Contains(id, ".") {
 
-		ok := valid[id]
+		ok := dottedIdentifiers[id]
 
 		if !ok {
-			for _, i := range types {
+			for _, i := range methodPrefixes {
 				if strings.HasPrefix(id, i) {
 					ok = true
 				}
@@ -297,6 +322,8 @@ func (l *Lexer) readIdentifier() string {
 
 			l.position = position
 			l.readPosition = rposition
+			l.ch = l.characters[position]
+			l.line, l.column = line, column
 			for offset > 0 {
 				l.readChar()
 				offset--
@@ -340,7 +367,7 @@ func (l *Lexer) skipMultiLineComment() {
 }
 
 func (l *Lexer) readNumber() string {
-	str := ""
+	position := l.position
 
 	accept := "0123456789"
 
@@ -352,11 +379,10 @@ func (l *Lexer) readNumber() string {
 		accept = "b01"
 	}
 
-	for strings.Contains(accept, string(l.ch)) {
-		str += string(l.ch)
+	for strings.ContainsRune(accept, l.ch) {
 		l.readChar()
 	}
-	return str
+	return string(l.characters[position:l.position])
 }
 func (l *Lexer) readDecimal() token.Token {
 
@@ -370,7 +396,7 @@ func (l *Lexer) readDecimal() token.Token {
 }
 
 func (l *Lexer) readString() string {
-	out := ""
+	var out strings.Builder
 
 	for {
 		l.readChar()
@@ -397,14 +423,14 @@ func (l *Lexer) readString() string {
 				l.ch = '\\'
 			}
 		}
-		out = out + string(l.ch)
+		out.WriteRune(l.ch)
 	}
 
-	return out
+	return out.String()
 }
 
 func (l *Lexer) readRegexp() (string, error) {
-	out := ""
+	var out strings.Builder
 
 	for {
 		l.readChar()
@@ -432,14 +458,14 @@ func (l *Lexer) readRegexp() (string, error) {
 			}
 
 			if len(flags) > 0 {
-				out = "(?" + flags + ")" + out
+				return "(?" + flags + ")" + out.String(), nil
 			}
 			break
 		}
-		out = out + string(l.ch)
+		out.WriteRune(l.ch)
 	}
 
-	return out, nil
+	return out.String(), nil
 }
 func (l *Lexer) readBacktick() string {
 	position := l.position + 1
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"scream/ast"
+	"scream/evaluator"
+	"scream/object"
+	"scream/token"
+)
+
+// runLines evaluates program once for each line read from files, or
+// from stdin if there are none, with LINE holding the line, NR its
+// number and FIELDS its fields.  Definitions made with FUNC are
+// evaluated once beforehand, followed by any BEGIN_INPUT blocks; END_INPUT
+// blocks run once the input is exhausted.  With -p, LINE is printed
+// after each run.
+func runLines(program *ast.Program, opts *options, files []string) int {
+	var sep *regexp.Regexp
+	if opts.fieldSep != "" {
+		var err error
+		sep, err = regexp.Compile(opts.fieldSep)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid field separator: %s\n", err.Error())
+			return 1
+		}
+	}
+
+	env := newEnvironment()
+	env.Set("LINE", &object.String{})
+	env.Set("NR", &object.Integer{})
+	env.Set("FIELDS", &object.Array{})
+	prepare(program, env)
+
+	defs, begin, body, end := &ast.Program{}, &ast.Program{}, &ast.Program{}, &ast.Program{}
+	for _, stmt := range program.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.InputBlock:
+			if stmt.Token.Type == token.BEGIN_INPUT {
+				begin.Statements = append(begin.Statements, stmt.Body.Statements...)
+			} else {
+				end.Statements = append(end.Statements, stmt.Body.Statements...)
+			}
+		case *ast.ExpressionStatement:
+			if _, ok := stmt.Expression.(*ast.FunctionDefineLiteral); ok {
+				defs.Statements = append(defs.Statements, stmt)
+			} else {
+				body.Statements = append(body.Statements, stmt)
+			}
+		default:
+			body.Statements = append(body.Statements, stmt)
+		}
+	}
+
+	out := env.Streams()
+	defer out.Flush()
+
+	for _, phase := range []*ast.Program{defs, begin} {
+		if isError(evaluator.Eval(phase, env)) {
+			return 1
+		}
+	}
+
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	nr := int64(0)
+	for _, name := range files {
+		failed := false
+		err := eachLine(name, out.Stdin, func(line string) bool {
+			nr++
+			env.Set("LINE", &object.String{Value: line})
+			env.Set("NR", &object.Integer{Value: nr})
+			env.Set("FIELDS", splitFields(line, sep))
+			if isError(evaluator.Eval(body, env)) {
+				failed = true
+				return false
+			}
+			if opts.printLines {
+				if val, ok := env.Get("LINE"); ok {
+					out.Print(val.Inspect() + "\n")
+				}
+			}
+			return true
+		})
+		if err != nil {
+			out.Diagnose(fmt.Sprintf("Error reading: %s\n", err.Error()))
+			return 1
+		}
+		if failed {
+			return 1
+		}
+	}
+
+	if isError(evaluator.Eval(end, env)) {
+		return 1
+	}
+	return 0
+}
+
+// eachLine calls fn with each line of the named file, or of stdin if
+// name is "-", without its line ending, until fn returns false.  The
+// file is closed before eachLine returns.
+func eachLine(name string, stdin *bufio.Reader, fn func(line string) bool) error {
+	in := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = bufio.NewReader(f)
+	}
+
+	for {
+		line, err := in.ReadString('\n')
+		if line == "" && err != nil {
+			if err != io.EOF {
+				return err
+			}
+			return nil
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if !fn(line) {
+			return nil
+		}
+	}
+}
+
+// splitFields splits line by sep, or around runs of whitespace if sep
+// is nil.  An empty line has no fields.
+func splitFields(line string, sep *regexp.Regexp) *object.Array {
+	var parts []string
+	if sep == nil {
+		parts = strings.Fields(line)
+	} else if line != "" {
+		parts = sep.Split(line, -1)
+	}
+	fields := make([]object.Object, len(parts))
+	for i, part := range parts {
+		fields[i] = &object.String{Value: part}
+	}
+	return &object.Array{Elements: fields}
+}
+
+func isError(obj object.Object) bool {
+	err, ok := obj.(*object.Error)
+	return ok && !err.Held
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLineMode(t *testing.T) {
+	dir := t.TempDir()
+	first := writeScript(t, dir, "first.txt", "a,b\nc,d\n")
+	second := writeScript(t, dir, "second.txt", "e,f")
+
+	tests := []struct {
+		opts     options
+		files    []string
+		input    string
+		expected string
+	}{
+		{options{lines: true, eval: `IF (LINE ~= /error/) { PRINT(LINE, "\n"); }`}, nil,
+			"ok\nerror 1\nfine\r\nerror 2\n", "error 1\nerror 2\n"},
+		{options{printLines: true, eval: `LET LINE = string(NR) + ": " + LINE;`}, nil,
+			"x\ny", "1: x\n2: y\n"},
+		{options{lines: true, fieldSep: ",", eval: `PRINT(FIELDS[1], NR, "\n");`}, []string{first, second},
+			"", "b1\nd2\nf3\n"},
+		{options{lines: true, eval: `PRINT(LEN(FIELDS));`}, nil,
+			"  one two\tthree \n\n", "30"},
+		{options{lines: true, eval: `BEGIN_INPUT { LET total = 0; PRINT("start "); }
+FUNC double(x) { x * 2 }
+LET total = total + double(int(LINE));
+END_INPUT { PRINT(total, " from ", NR); }`}, nil,
+			"1\n2\n3\n", "start 12 from 3"},
+	}
+
+	for _, tt := range tests {
+		status, out, _ := runCLI(t, &tt.opts, tt.files, tt.input)
+		if status != 0 || out != tt.expected {
+			t.Errorf("got status %d and %q, want %q for %s", status, out, tt.expected, tt.opts.eval)
+		}
+	}
+}
+
+func TestLineModeErrors(t *testing.T) {
+	status, _, errs := runCLI(t, &options{lines: true, eval: `PRINT(LINE);`}, []string{"/no/such/file"}, "")
+	if status != 1 || !strings.Contains(errs, "Error reading") {
+		t.Errorf("got status %d and %q for a missing file", status, errs)
+	}
+
+	status, _, _ = runCLI(t, &options{lines: true, fieldSep: "(", eval: `PRINT(LINE);`}, nil, "x\n")
+	if status != 1 {
+		t.Errorf("got status %d for an invalid separator", status)
+	}
+
+	status, out, _ := runCLI(t, &options{lines: true, eval: `PRINT(LINE); IF (NR == 2) { exit(7); }`}, nil, "a\nb\nc\n")
+	if status != 7 || out != "ab" {
+		t.Errorf("got status %d and %q when exiting early", status, out)
+	}
+}
@@ -0,0 +1,339 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	objectType = reflect.TypeOf((*Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// FromGo converts a Go value into the equivalent SCREAM object.
+//
+// Integers of every size become INTEGER, floats become FLOAT, and
+// slices and arrays become ARRAY.  Maps and structs become HASH; a
+// struct field is keyed by its name, or by the name given in a
+// `scream:"name"` tag, and fields tagged `scream:"-"` or unexported are
+// skipped.  Pointers and interfaces are followed, nil becomes NULL, an
+// error becomes an ERROR, and an Object is returned unchanged.
+func FromGo(v interface{}) (Object, error) {
+	if v == nil {
+		return &Null{}, nil
+	}
+	if obj, ok := v.(Object); ok {
+		return obj, nil
+	}
+	return fromValue(reflect.ValueOf(v))
+}
+
+func fromValue(v reflect.Value) (Object, error) {
+	if !v.IsValid() {
+		return &Null{}, nil
+	}
+	if isNilValue(v) {
+		return &Null{}, nil
+	}
+	if v.Type().Implements(objectType) {
+		return v.Interface().(Object), nil
+	}
+	if t := hostTypeOf(v.Type()); t != nil {
+		return t.New(v.Interface()), nil
+	}
+	if v.Type().Implements(errorType) {
+		return &Error{Message: v.Interface().(error).Error()}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return &Boolean{Value: v.Bool()}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d does not fit in an INTEGER", v.Uint())
+		}
+		return &Integer{Value: int64(v.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Float{Value: v.Float()}, nil
+	case reflect.String:
+		return &String{Value: v.String()}, nil
+	case reflect.Ptr, reflect.Interface:
+		return fromValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		elements := make([]Object, v.Len())
+		for i := range elements {
+			e, err := fromValue(v.Index(i))
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %s", i, err)
+			}
+			elements[i] = e
+		}
+		return &Array{Elements: elements}, nil
+	case reflect.Map:
+		hash := &Hash{Pairs: make(map[HashKey]HashPair)}
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := fromValue(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			hashable, ok := AsHashable(key)
+			if !ok {
+				return nil, fmt.Errorf("%s cannot be used as a hash key", key.Type())
+			}
+			value, err := fromValue(iter.Value())
+			if err != nil {
+				return nil, fmt.Errorf("key %s: %s", key.Inspect(), err)
+			}
+			hash.Pairs[hashable.HashKey()] = HashPair{Key: key, Value: value}
+		}
+		return hash, nil
+	case reflect.Struct:
+		hash := &Hash{Pairs: make(map[HashKey]HashPair)}
+		for _, f := range structFields(v.Type()) {
+			value, err := fromValue(v.FieldByIndex(f.index))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %s", f.name, err)
+			}
+			key := &String{Value: f.name}
+			hash.Pairs[key.HashKey()] = HashPair{Key: key, Value: value}
+		}
+		return hash, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to a SCREAM object", v.Type())
+}
+
+// ToGo converts a SCREAM object into a plain Go value: int64, float64,
+// string, bool, nil, []interface{} or an error.  Ranges become slices.  A hash whose keys are
+// all strings becomes a map[string]interface{}, any other hash a
+// map[interface{}]interface{}.  Objects with no Go equivalent, such as
+// functions, are returned as their ToInterface value.
+func ToGo(obj Object) interface{} {
+	switch obj := obj.(type) {
+	case nil, *Null:
+		return nil
+	case *Integer:
+		return obj.Value
+	case *Float:
+		return obj.Value
+	case *String:
+		return obj.Value
+	case *Boolean:
+		return obj.Value
+	case *Error:
+		return errors.New(obj.Message)
+	case *ReturnValue:
+		return ToGo(obj.Value)
+	case *Range:
+		return ToGo(obj.ToArray())
+	case *Array:
+		out := make([]interface{}, len(obj.Elements))
+		for i, e := range obj.Elements {
+			out[i] = ToGo(e)
+		}
+		return out
+	case *Hash:
+		strs := make(map[string]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			key, ok := pair.Key.(*String)
+			if !ok {
+				mixed := make(map[interface{}]interface{}, len(obj.Pairs))
+				for _, pair := range obj.Pairs {
+					mixed[ToGo(pair.Key)] = ToGo(pair.Value)
+				}
+				return mixed
+			}
+			strs[key.Value] = ToGo(pair.Value)
+		}
+		return strs
+	}
+	return obj.ToInterface()
+}
+
+// ToGoValue converts a SCREAM object into a Go value of type t, for
+// passing to Go functions whose parameter types are known.  Integers
+// are range checked and may be passed where a float is wanted; hashes
+// fill structs by the same names FromGo produces.
+func ToGoValue(obj Object, t reflect.Type) (reflect.Value, error) {
+	if r, ok := obj.(*ReturnValue); ok {
+		obj = r.Value
+	}
+	if obj == nil {
+		obj = &Null{}
+	}
+	// Every object would do for interface{}, which is given plain Go
+	// values instead.
+	empty := t.Kind() == reflect.Interface && t.NumMethod() == 0
+	if !empty && reflect.TypeOf(obj).AssignableTo(t) {
+		return reflect.ValueOf(obj), nil
+	}
+	if h, ok := obj.(*HostObject); ok && h.Value != nil && reflect.TypeOf(h.Value).AssignableTo(t) {
+		return reflect.ValueOf(h.Value), nil
+	}
+
+	fail := func() (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+	}
+
+	if _, ok := obj.(*Null); ok {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			return reflect.Zero(t), nil
+		}
+		return fail()
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return fail()
+		}
+		v := ToGo(obj)
+		if v == nil {
+			return reflect.Zero(t), nil
+		}
+		return reflect.ValueOf(v), nil
+	case reflect.Bool:
+		if b, ok := obj.(*Boolean); ok {
+			return reflect.ValueOf(b.Value).Convert(t), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*Integer); ok {
+			v := reflect.New(t).Elem()
+			if v.OverflowInt(i.Value) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			v.SetInt(i.Value)
+			return v, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*Integer); ok {
+			v := reflect.New(t).Elem()
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return reflect.Value{}, fmt.Errorf("%d overflows %s", i.Value, t)
+			}
+			v.SetUint(uint64(i.Value))
+			return v, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *Float:
+			return reflect.ValueOf(n.Value).Convert(t), nil
+		case *Integer:
+			return reflect.ValueOf(float64(n.Value)).Convert(t), nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*String); ok {
+			return reflect.ValueOf(s.Value).Convert(t), nil
+		}
+	case reflect.Ptr:
+		elem, err := ToGoValue(obj, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(elem)
+		return p, nil
+	case reflect.Slice:
+		if r, ok := obj.(*Range); ok {
+			obj = r.ToArray()
+		}
+		arr, ok := obj.(*Array)
+		if !ok {
+			return fail()
+		}
+		v := reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+		for i, e := range arr.Elements {
+			ev, err := ToGoValue(e, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("index %d: %s", i, err)
+			}
+			v.Index(i).Set(ev)
+		}
+		return v, nil
+	case reflect.Map:
+		hash, ok := obj.(*Hash)
+		if !ok {
+			return fail()
+		}
+		v := reflect.MakeMapWithSize(t, len(hash.Pairs))
+		for _, pair := range hash.Pairs {
+			kv, err := ToGoValue(pair.Key, t.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			vv, err := ToGoValue(pair.Value, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("key %s: %s", pair.Key.Inspect(), err)
+			}
+			v.SetMapIndex(kv, vv)
+		}
+		return v, nil
+	case reflect.Struct:
+		hash, ok := obj.(*Hash)
+		if !ok {
+			return fail()
+		}
+		v := reflect.New(t).Elem()
+		for _, f := range structFields(t) {
+			key := &String{Value: f.name}
+			pair, ok := hash.Pairs[key.HashKey()]
+			if !ok {
+				continue
+			}
+			fv, err := ToGoValue(pair.Value, t.FieldByIndex(f.index).Type)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("field %s: %s", f.name, err)
+			}
+			v.FieldByIndex(f.index).Set(fv)
+		}
+		return v, nil
+	}
+	return fail()
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields lists the exported fields of a struct type under the
+// names they have in a SCREAM hash, in name order.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("scream"); ok {
+			tag = strings.Split(tag, ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, structField{name: name, index: f.Index})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	return fields
+}
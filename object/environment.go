@@ -4,9 +4,27 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 )
 
+// OnSet, when non-nil, is called after every variable assignment, with
+// the environment the assignment was made in.
+var OnSet func(env *Environment, name string, val Object)
+
+// Apply calls a function object.  It is set by the evaluator, so that
+// objects such as Mutex can call back into the script.
+var Apply func(env *Environment, fn Object, args ...Object) Object
+
+// Exit terminates the interpreter.  Hosts which must outlive the script,
+// such as the watch command, may replace it.
+var Exit = os.Exit
+
+// Environment holds the variables of a scope.  It may be shared by
+// tasks running concurrently, so its store is guarded by mu, which is a
+// pointer since methods receive environments by value.
 type Environment struct {
+	mu *sync.RWMutex
+
 	store map[string]Object
 
 	readonly map[string]bool
@@ -14,12 +32,46 @@ type Environment struct {
 	outer *Environment
 
 	permit []string
+
+	streams *Streams
+
+	hooks []Hook
+
+	yield func(Object)
+
+	defers *Defers
+
+	frames []string
+
+	exit func(code int)
+}
+
+// Defers holds the code deferred within a function call.
+type Defers struct {
+	fns []func() Object
+}
+
+// Add defers fn until the call returns.
+func (d *Defers) Add(fn func() Object) {
+	d.fns = append(d.fns, fn)
+}
+
+// Run runs the deferred code, most recently deferred first, returning
+// what each piece of it evaluated to in the order it ran.
+func (d *Defers) Run() []Object {
+	var results []Object
+	for len(d.fns) > 0 {
+		fn := d.fns[len(d.fns)-1]
+		d.fns = d.fns[:len(d.fns)-1]
+		results = append(results, fn())
+	}
+	return results
 }
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
 	r := make(map[string]bool)
-	return &Environment{store: s, readonly: r, outer: nil}
+	return &Environment{mu: &sync.RWMutex{}, store: s, readonly: r, outer: nil}
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
@@ -35,9 +87,114 @@ func NewTemporaryScope(outer *Environment, keys []string) *Environment {
 	return env
 }
 
+// SetStreams makes the environment, and every scope enclosed by it, use
+// the given streams for input and output.
+func (e *Environment) SetStreams(s *Streams) {
+	e.streams = s
+}
+
+// Streams returns the streams of the nearest environment which has been
+// given any, or Stdio.
+func (e *Environment) Streams() *Streams {
+	for env := e; env != nil; env = env.outer {
+		if env.streams != nil {
+			return env.streams
+		}
+	}
+	return Stdio
+}
+
+// AddHook registers a hook which observes everything evaluated in the
+// environment and the scopes it encloses.
+func (e *Environment) AddHook(h Hook) {
+	e.hooks = append(e.hooks, h)
+}
+
+// Hooks returns the hooks of the nearest environment which has any.
+func (e *Environment) Hooks() []Hook {
+	for env := e; env != nil; env = env.outer {
+		if env.hooks != nil {
+			return env.hooks
+		}
+	}
+	return nil
+}
+
+// SetYield makes YIELD within the environment, and every scope enclosed
+// by it, hand its values to yield.
+func (e *Environment) SetYield(yield func(Object)) {
+	e.yield = yield
+}
+
+// Yield returns the function YIELD hands its values to, or nil outside
+// of a generator.
+func (e *Environment) Yield() func(Object) {
+	for env := e; env != nil; env = env.outer {
+		if env.yield != nil {
+			return env.yield
+		}
+	}
+	return nil
+}
+
+// SetDefers makes DEFER within the environment, and every scope enclosed
+// by it, add to defers.
+func (e *Environment) SetDefers(defers *Defers) {
+	e.defers = defers
+}
+
+// Defers returns the code deferred by the innermost function call, or
+// nil outside of one.
+func (e *Environment) Defers() *Defers {
+	for env := e; env != nil; env = env.outer {
+		if env.defers != nil {
+			return env.defers
+		}
+	}
+	return nil
+}
+
+// SetFrames records the names of the calls, outermost first, by which
+// evaluation reached the environment and the scopes it encloses.
+func (e *Environment) SetFrames(frames []string) {
+	e.frames = frames
+}
+
+// Frames returns the calls recorded by the nearest environment which
+// has any.  A call is given a slice of its own, so tasks running at the
+// same time never share one they may change.
+func (e *Environment) Frames() []string {
+	for env := e; env != nil; env = env.outer {
+		if env.frames != nil {
+			return env.frames
+		}
+	}
+	return nil
+}
+
+// SetExit makes exit() within the environment, and every scope enclosed
+// by it, call exit in place of Exit.  exit must not return.
+func (e *Environment) SetExit(exit func(code int)) {
+	e.exit = exit
+}
+
+// Exit ends the script with the given status, by the function given to
+// the nearest environment which has one, or by Exit.
+func (e *Environment) Exit(code int) {
+	for env := e; env != nil; env = env.outer {
+		if env.exit != nil {
+			env.exit(code)
+			return
+		}
+	}
+	Exit(code)
+}
+
 func (e *Environment) Names(prefix string) []string {
 	var ret []string
 
+	e.mu.RLock()
+	defer e.mu.RUnlock()
 	for key := range e.store {
 		if strings.HasPrefix(key, prefix) {
 			ret = append(ret, key)
@@ -51,40 +208,100 @@ func (e *Environment) Names(prefix string) []string {
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
-	obj, ok := e.store[name]
+	obj, ok := e.lookup(name)
 	if !ok && e.outer != nil {
 		obj, ok = e.outer.Get(name)
 	}
 	return obj, ok
 }
 
+// GetAt looks name up in the environment depth levels out from this
+// one, without searching any other environment.
+func (e *Environment) GetAt(depth int, name string) (Object, bool) {
+	env := e
+	for i := 0; i < depth && env != nil; i++ {
+		env = env.outer
+	}
+	if env == nil {
+		return nil, false
+	}
+	return env.lookup(name)
+}
+
+func (e *Environment) lookup(name string) (Object, bool) {
+	e.mu.RLock()
+	obj, ok := e.store[name]
+	e.mu.RUnlock()
+	return obj, ok
+}
+
+func (e *Environment) put(name string, val Object) {
+	e.mu.Lock()
+	e.store[name] = val
+	e.mu.Unlock()
+}
+
 func (e *Environment) Set(name string, val Object) Object {
 
-	cur := e.store[name]
-	if cur != nil && e.readonly[name] {
-		fmt.Printf("Attempting to modify '%s' denied; it was defined as a constant.\n", name)
-		os.Exit(3)
+	e.mu.RLock()
+	constant := e.store[name] != nil && e.readonly[name]
+	e.mu.RUnlock()
+	if constant {
+		out := e.Streams()
+		out.Print(fmt.Sprintf("Attempting to modify '%s' denied; it was defined as a constant.\n", name))
+		out.Flush()
+		e.Exit(3)
 	}
 
 	if len(e.permit) > 0 {
 		for _, v := range e.permit {
 			if v == name {
-				e.store[name] = val
+				if err := e.vetoSet(name, val); err != nil {
+					return err
+				}
+				e.put(name, val)
+				if OnSet != nil {
+					OnSet(e, name, val)
+				}
 				return val
 			}
 		}
 		if e.outer != nil {
 			return e.outer.Set(name, val)
 		}
-		fmt.Printf("scoping weirdness; please report a bug\n")
-		os.Exit(5)
+		out := e.Streams()
+		out.Print("scoping weirdness; please report a bug\n")
+		out.Flush()
+		e.Exit(5)
+	}
+	if err := e.vetoSet(name, val); err != nil {
+		return err
+	}
+	e.put(name, val)
+	if OnSet != nil {
+		OnSet(e, name, val)
 	}
-	e.store[name] = val
 	return val
 }
 
+// vetoSet asks the hooks whether name may be set, returning the error
+// to fail with if not.
+func (e *Environment) vetoSet(name string, val Object) *Error {
+	for _, h := range e.Hooks() {
+		if err := h.BeforeSet(name, val); err != nil {
+			return &Error{Message: err.Error()}
+		}
+	}
+	return nil
+}
+
 func (e *Environment) SetConst(name string, val Object) Object {
+	if err := e.vetoSet(name, val); err != nil {
+		return err
+	}
+	e.mu.Lock()
 	e.store[name] = val
 	e.readonly[name] = true
+	e.mu.Unlock()
 	return val
 }
@@ -0,0 +1,37 @@
+package object
+
+// Hook lets a host program observe a running script, and veto some of
+// what it does.  Methods returning an error veto the action: a vetoed
+// call or assignment fails with that error, while vetoed output and
+// pragma changes are dropped.  Embed NopHook to implement only the
+// methods of interest.
+type Hook interface {
+	// BeforeCall is invoked before any function or builtin is called.
+	BeforeCall(name string, args []Object) error
+
+	// AfterCall is invoked with the result of every call which ran.
+	AfterCall(name string, args []Object, result Object)
+
+	// BeforeSet is invoked before a variable is assigned.
+	BeforeSet(name string, val Object) error
+
+	// OnError is invoked once for each error a script raises, as soon as
+	// it is returned by a call or ends a statement.
+	OnError(err *Error)
+
+	// BeforePrint is invoked with text a script is about to print.
+	BeforePrint(text string) error
+
+	// BeforePragma is invoked before a pragma is enabled or disabled.
+	BeforePragma(name string, enabled bool) error
+}
+
+// NopHook implements Hook without observing or vetoing anything.
+type NopHook struct{}
+
+func (NopHook) BeforeCall(name string, args []Object) error         { return nil }
+func (NopHook) AfterCall(name string, args []Object, result Object) {}
+func (NopHook) BeforeSet(name string, val Object) error             { return nil }
+func (NopHook) OnError(err *Error)                                  {}
+func (NopHook) BeforePrint(text string) error                       { return nil }
+func (NopHook) BeforePragma(name string, enabled bool) error        { return nil }
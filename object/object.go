@@ -8,6 +8,7 @@ const (
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	LOOP_CONTROL_OBJ = "LOOP_CONTROL"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	STRING_OBJ       = "STRING"
@@ -16,6 +17,14 @@ const (
 	HASH_OBJ         = "HASH"
 	FILE_OBJ         = "FILE"
 	REGEXP_OBJ       = "REGEXP"
+	RANGE_OBJ        = "RANGE"
+	GENERATOR_OBJ    = "GENERATOR"
+	TASK_OBJ         = "TASK"
+	CHANNEL_OBJ      = "CHANNEL"
+	MUTEX_OBJ        = "MUTEX"
+	WAITGROUP_OBJ    = "WAITGROUP"
+	ATOMIC_OBJ       = "ATOMIC"
+	POOL_OBJ         = "POOL"
 )
 
 type Object interface {
@@ -0,0 +1,96 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Atomic is an integer which tasks may update concurrently.
+type Atomic struct {
+	value int64
+}
+
+func NewAtomic(value int64) *Atomic {
+	return &Atomic{value: value}
+}
+
+func (a *Atomic) Load() int64 {
+	return atomic.LoadInt64(&a.value)
+}
+
+func (a *Atomic) Type() Type {
+	return ATOMIC_OBJ
+}
+
+func (a *Atomic) Inspect() string {
+	return fmt.Sprintf("%d", a.Load())
+}
+
+func (a *Atomic) InvokeMethod(method string, env Environment, args ...Object) Object {
+	ints := func(want int) ([]int64, *Error) {
+		if len(args) != want {
+			return nil, &Error{Message: fmt.Sprintf("wrong number of arguments to `%s`. got=%d, want=%d", method, len(args), want)}
+		}
+		vals := make([]int64, want)
+		for i, arg := range args {
+			n, ok := arg.(*Integer)
+			if !ok {
+				return nil, &Error{Message: fmt.Sprintf("argument to `%s` must be INTEGER, got=%s", method, arg.Type())}
+			}
+			vals[i] = n.Value
+		}
+		return vals, nil
+	}
+
+	switch method {
+	case "get":
+		return &Integer{Value: a.Load()}
+	case "set":
+		vals, err := ints(1)
+		if err != nil {
+			return err
+		}
+		atomic.StoreInt64(&a.value, vals[0])
+		return &Integer{Value: vals[0]}
+	case "add":
+		vals, err := ints(1)
+		if err != nil {
+			return err
+		}
+		return &Integer{Value: atomic.AddInt64(&a.value, vals[0])}
+	case "inc":
+		return &Integer{Value: atomic.AddInt64(&a.value, 1)}
+	case "dec":
+		return &Integer{Value: atomic.AddInt64(&a.value, -1)}
+	case "swap":
+		vals, err := ints(2)
+		if err != nil {
+			return err
+		}
+		return &Boolean{Value: atomic.CompareAndSwapInt64(&a.value, vals[0], vals[1])}
+	case "methods":
+		static := []string{"add", "dec", "get", "inc", "methods", "set", "swap"}
+		dynamic := env.Names("atomic.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+func (a *Atomic) ToInterface() interface{} {
+	return a.Load()
+}
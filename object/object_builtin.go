@@ -2,7 +2,8 @@ package object
 
 type BuiltinFunction func(env *Environment, args ...Object) Object
 type Builtin struct {
-	Fn BuiltinFunction
+	Name string
+	Fn   BuiltinFunction
 }
 
 func (b *Builtin) Type() Type {
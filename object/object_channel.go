@@ -0,0 +1,119 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Channel passes values between tasks.  Iterating over a channel
+// receives from it until it is closed.
+type Channel struct {
+	C chan Object
+
+	received int64
+}
+
+// NewChannel returns a channel buffering up to size values.
+func NewChannel(size int) *Channel {
+	return &Channel{C: make(chan Object, size)}
+}
+
+// Send sends val, waiting for room in the buffer or a receiver.  It
+// fails if the channel has been closed.
+func (c *Channel) Send(val Object) (err *Error) {
+	defer func() {
+		if recover() != nil {
+			err = &Error{Message: "send on closed channel"}
+		}
+	}()
+	c.C <- val
+	return nil
+}
+
+// Recv waits for a value, reporting false once the channel has been
+// closed and emptied.
+func (c *Channel) Recv() (Object, bool) {
+	val, ok := <-c.C
+	return val, ok
+}
+
+// Close stops the channel accepting values.  It fails if the channel
+// has already been closed.
+func (c *Channel) Close() (err *Error) {
+	defer func() {
+		if recover() != nil {
+			err = &Error{Message: "close of closed channel"}
+		}
+	}()
+	close(c.C)
+	return nil
+}
+
+func (c *Channel) Type() Type {
+	return CHANNEL_OBJ
+}
+
+func (c *Channel) Inspect() string {
+	return fmt.Sprintf("<channel %d/%d>", len(c.C), cap(c.C))
+}
+
+func (c *Channel) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "send":
+		if len(args) != 1 {
+			return &Error{Message: fmt.Sprintf("wrong number of arguments to `send`. got=%d, want=1", len(args))}
+		}
+		if err := c.Send(args[0]); err != nil {
+			return err
+		}
+		return &Null{}
+	case "recv":
+		val, ok := c.Recv()
+		if !ok {
+			return &Null{}
+		}
+		return val
+	case "close":
+		if err := c.Close(); err != nil {
+			return err
+		}
+		return &Null{}
+	case "len":
+		return &Integer{Value: int64(len(c.C))}
+	case "methods":
+		static := []string{"close", "len", "methods", "recv", "send"}
+		dynamic := env.Names("channel.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+// Reset does nothing; values once received are gone.
+func (c *Channel) Reset() {}
+
+func (c *Channel) Next() (Object, Object, bool) {
+	val, ok := c.Recv()
+	if !ok {
+		return nil, &Integer{Value: 0}, false
+	}
+	return val, &Integer{Value: atomic.AddInt64(&c.received, 1) - 1}, true
+}
+
+func (c *Channel) ToInterface() interface{} {
+	return "<CHANNEL>"
+}
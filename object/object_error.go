@@ -1,7 +1,57 @@
 package object
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type Error struct {
 	Message string
+
+	// Kind classifies the error; errors without one are of kind "error".
+	Kind string
+
+	// Cause is the error this one wraps, if any.
+	Cause *Error
+
+	// Line and Column give the position of the statement which raised
+	// the error, once it is known.
+	Line   int
+	Column int
+
+	// Held errors are ordinary values, which scripts may pass around and
+	// inspect.  Other errors are thrown: they end evaluation of every
+	// enclosing statement until they are caught by try.
+	Held bool
+
+	// Reported is set once the error has been passed to the OnError
+	// hooks, so they see each error only once.
+	Reported bool
+}
+
+// Wrap returns a thrown error which has cause as its cause, with a
+// message followed by that of cause.
+func Wrap(cause *Error, format string, a ...interface{}) *Error {
+	return &Error{
+		Message: fmt.Sprintf(format, a...) + ": " + cause.Message,
+		Kind:    cause.Kind,
+		Cause:   cause,
+	}
+}
+
+// AsValue returns a copy of e which is an ordinary value.
+func (e *Error) AsValue() *Error {
+	held := *e
+	held.Held = true
+	return &held
+}
+
+// Thrown returns a copy of e which will be thrown.
+func (e *Error) Thrown() *Error {
+	thrown := *e
+	thrown.Held = false
+	return &thrown
 }
 
 func (e *Error) Type() Type {
@@ -12,7 +62,47 @@ func (e *Error) Inspect() string {
 }
 
 func (e *Error) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "message":
+		return &String{Value: e.Message}
+	case "kind":
+		if e.Kind == "" {
+			return &String{Value: "error"}
+		}
+		return &String{Value: e.Kind}
+	case "cause", "unwrap":
+		if e.Cause == nil {
+			return &Null{}
+		}
+		return e.Cause.AsValue()
+	case "position":
+		pos := &Hash{Pairs: make(map[HashKey]HashPair)}
+		for _, field := range []struct {
+			name  string
+			value int
+		}{{"line", e.Line}, {"column", e.Column}} {
+			key := &String{Value: field.name}
+			pos.Pairs[key.HashKey()] = HashPair{Key: key, Value: &Integer{Value: int64(field.value)}}
+		}
+		return pos
+	case "methods":
+		static := []string{"cause", "kind", "message", "methods", "position", "unwrap"}
+		dynamic := env.Names("error.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
 
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
 	return nil
 }
 
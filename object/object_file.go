@@ -16,6 +16,10 @@ type File struct {
 	Writer *bufio.Writer
 
 	Handle *os.File
+
+	// Streams back the !STDIN!, !STDOUT! and !STDERR! pseudo-files;
+	// when nil, Stdio is used.
+	Streams *Streams
 }
 
 func (f *File) Type() Type {
@@ -27,16 +31,19 @@ func (f *File) Inspect() string {
 }
 func (f *File) Open(mode string) error {
 
+	if f.Streams == nil {
+		f.Streams = Stdio
+	}
 	if f.Filename == "!STDIN!" {
-		f.Reader = bufio.NewReader(os.Stdin)
+		f.Reader = f.Streams.Stdin
 		return nil
 	}
 	if f.Filename == "!STDOUT!" {
-		f.Writer = bufio.NewWriter(os.Stdout)
+		f.Writer = f.Streams.Stdout
 		return nil
 	}
 	if f.Filename == "!STDERR!" {
-		f.Writer = bufio.NewWriter(os.Stderr)
+		f.Writer = f.Streams.Stderr
 		return nil
 	}
 
@@ -84,6 +91,8 @@ func (f *File) InvokeMethod(method string, env Environment, args ...Object) Obje
 			return (&Null{})
 		}
 
+		f.flushPrompt()
+
 		// Result.
 		var lines []string
 		for {
@@ -126,6 +135,8 @@ func (f *File) InvokeMethod(method string, env Environment, args ...Object) Obje
 			return (&String{Value: ""})
 		}
 
+		f.flushPrompt()
+
 		// Read and return a line.
 		line, err := f.Reader.ReadString('\n')
 		if err != nil {
@@ -152,6 +163,17 @@ func (f *File) InvokeMethod(method string, env Environment, args ...Object) Obje
 
 		// Write the text - coorcing to a string first.
 		txt := args[0].Inspect()
+
+		// The standard streams are shared between tasks.
+		switch f.Filename {
+		case "!STDOUT!":
+			f.Streams.Print(txt)
+			f.Streams.Flush()
+			return &Boolean{Value: true}
+		case "!STDERR!":
+			f.Streams.Diagnose(txt)
+			return &Boolean{Value: true}
+		}
 		_, err := f.Writer.Write([]byte(txt))
 		if err == nil {
 			f.Writer.Flush()
@@ -163,6 +185,14 @@ func (f *File) InvokeMethod(method string, env Environment, args ...Object) Obje
 	return nil
 }
 
+// flushPrompt writes out pending output before reading from stdin, so
+// that any prompt is visible.
+func (f *File) flushPrompt() {
+	if f.Filename == "!STDIN!" {
+		f.Streams.Flush()
+	}
+}
+
 // ToInterface converts this object to a go-interface, which will allow
 // it to be used naturally in our sprintf/printf primitives.
 //
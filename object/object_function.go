@@ -9,10 +9,34 @@ import (
 )
 
 type Function struct {
+	Name       string
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Defaults   map[string]ast.Expression
+	Patterns   map[string]ast.Expression
 	Env        *Environment
+	Generator  bool
+	Variadic   bool
+}
+
+// Signature describes how the function is called, as its name followed
+// by its parameters and their defaults.
+func (f *Function) Signature() string {
+	name := f.Name
+	if name == "" {
+		name = "FN"
+	}
+	params := make([]string, len(f.Parameters))
+	for i, p := range f.Parameters {
+		params[i] = p.String()
+		if def, ok := f.Defaults[p.Value]; ok {
+			params[i] += " = " + def.String()
+		}
+	}
+	if f.Variadic {
+		params[len(params)-1] = "..." + params[len(params)-1]
+	}
+	return name + "(" + strings.Join(params, ", ") + ")"
 }
 
 func (f *Function) Type() Type {
@@ -25,6 +49,9 @@ func (f *Function) Inspect() string {
 	for _, p := range f.Parameters {
 		parameters = append(parameters, p.String())
 	}
+	if f.Variadic {
+		parameters[len(parameters)-1] = "..." + parameters[len(parameters)-1]
+	}
 	out.WriteString("fn")
 	out.WriteString("(")
 	out.WriteString(strings.Join(parameters, ", "))
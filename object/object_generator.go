@@ -0,0 +1,180 @@
+package object
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// abandoned is panicked by yield to unwind the body of a generator
+// which has been closed.
+type abandoned struct{}
+
+// Generator produces the values yielded by a call of a function whose
+// body contains YIELD.  The body runs in a goroutine of its own, but
+// only while a value is being waited for, so it never runs alongside
+// the script.  A generator which is closed, or which becomes garbage,
+// unwinds its body so that the goroutine is not leaked.
+type Generator struct {
+	Name string
+
+	state *generatorState
+	index int64
+}
+
+// generatorState is shared with the goroutine running the body, which
+// must not refer to the Generator itself or it could never become
+// garbage.  mu serialises the tasks, and the finalizer, which may all
+// resume or close the same generator.
+type generatorState struct {
+	mu      sync.Mutex
+	body    func(yield func(Object)) Object
+	started bool
+	done    bool
+	resume  chan bool
+	values  chan Object
+	err     *Error
+}
+
+// NewGenerator returns a generator producing the values body passes to
+// yield.  body is not called until the first value is wanted, and what
+// it returns is discarded unless it is an error.
+func NewGenerator(name string, body func(yield func(Object)) Object) *Generator {
+	g := &Generator{Name: name, state: &generatorState{body: body}}
+	runtime.SetFinalizer(g, func(g *Generator) {
+		g.Close()
+	})
+	return g
+}
+
+func (s *generatorState) run() {
+	defer close(s.values)
+	// exited stays true only if exit() ends the goroutine.
+	exited := true
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(abandoned); !ok {
+				panic(r)
+			}
+			exited = false
+		}
+		if exited {
+			s.err = &Error{Message: "generator ended by exit()"}
+		}
+	}()
+	if !<-s.resume {
+		exited = false
+		return
+	}
+	res := s.body(s.yield)
+	exited = false
+	if err, ok := res.(*Error); ok && !err.Held {
+		s.err = err
+	}
+}
+
+func (s *generatorState) yield(val Object) {
+	s.values <- val
+	if !<-s.resume {
+		panic(abandoned{})
+	}
+}
+
+func (g *Generator) Type() Type {
+	return GENERATOR_OBJ
+}
+
+func (g *Generator) Inspect() string {
+	if g.Name == "" {
+		return "<generator>"
+	}
+	return "<generator " + g.Name + ">"
+}
+
+func (g *Generator) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "next":
+		val, _, ok := g.Next()
+		if !ok {
+			return &Null{}
+		}
+		return val
+	case "close":
+		g.Close()
+		return &Null{}
+	case "methods":
+		static := []string{"close", "methods", "next"}
+		dynamic := env.Names("generator.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+// Reset does nothing, since a generator cannot be rewound; iterating
+// over it again carries on from where the last iteration stopped.
+func (g *Generator) Reset() {}
+
+func (g *Generator) Next() (Object, Object, bool) {
+	s := g.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return nil, &Integer{Value: 0}, false
+	}
+	if !s.started {
+		s.started = true
+		s.resume = make(chan bool)
+		s.values = make(chan Object)
+		go s.run()
+	}
+
+	s.resume <- true
+	val, ok := <-s.values
+	if !ok {
+		s.done = true
+		return nil, &Integer{Value: 0}, false
+	}
+	g.index++
+	return val, &Integer{Value: g.index - 1}, true
+}
+
+// Close abandons the generator, unwinding its body if it has started.
+func (g *Generator) Close() {
+	s := g.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	s.done = true
+	if s.started {
+		s.resume <- false
+		for range s.values {
+		}
+	}
+}
+
+// Err returns the error which ended the generator's body, if any.
+func (g *Generator) Err() *Error {
+	g.state.mu.Lock()
+	defer g.state.mu.Unlock()
+	return g.state.err
+}
+
+func (g *Generator) ToInterface() interface{} {
+	return "<GENERATOR>"
+}
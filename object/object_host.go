@@ -0,0 +1,201 @@
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// HostMethod implements a method of a host type.  It receives the object
+// the method was invoked upon along with the script's arguments.
+type HostMethod func(obj *HostObject, args ...Object) Object
+
+// HostType describes a type defined by the program embedding the
+// interpreter, such as a database handle, so that scripts can use its
+// values like any other object.
+type HostType struct {
+	// Name is returned by type(), and prefixes script-defined methods
+	// for the type; it must be unique.
+	Name string
+
+	// Methods are invoked as obj.name(args).
+	Methods map[string]HostMethod
+
+	// Properties are read as obj["name"], or obj.name().
+	Properties map[string]func(obj *HostObject) Object
+
+	// Iterate, if set, lets the type be used with foreach.  It returns a
+	// function producing each value and index in turn, which reports
+	// false once there are no more.
+	Iterate func(obj *HostObject) func() (Object, Object, bool)
+
+	// Hash, if set, lets values of the type be used as hash keys.
+	Hash func(obj *HostObject) uint64
+
+	// GoType, if set, makes FromGo wrap Go values of this type in a
+	// HostObject rather than converting them.
+	GoType reflect.Type
+}
+
+var hostTypes = map[string]*HostType{}
+
+// RegisterType adds a host type to the registry.
+func RegisterType(t *HostType) error {
+	if t.Name == "" {
+		return fmt.Errorf("host type has no name")
+	}
+	if _, ok := hostTypes[t.Name]; ok {
+		return fmt.Errorf("host type %s is already registered", t.Name)
+	}
+	if t.GoType != nil {
+		if other := hostTypeOf(t.GoType); other != nil {
+			return fmt.Errorf("%s is already registered as host type %s", t.GoType, other.Name)
+		}
+	}
+	hostTypes[t.Name] = t
+	return nil
+}
+
+// LookupType returns the registered host type with the given name.
+func LookupType(name string) (*HostType, bool) {
+	t, ok := hostTypes[name]
+	return t, ok
+}
+
+// New wraps a Go value as an object of this type.
+func (t *HostType) New(value interface{}) *HostObject {
+	return &HostObject{HostType: t, Value: value}
+}
+
+func hostTypeOf(rt reflect.Type) *HostType {
+	for _, t := range hostTypes {
+		if t.GoType == rt {
+			return t
+		}
+	}
+	return nil
+}
+
+// HostObject is a value of a host type.
+type HostObject struct {
+	HostType *HostType
+	Value    interface{}
+
+	next func() (Object, Object, bool)
+}
+
+func (h *HostObject) Type() Type {
+	return Type(strings.ToUpper(h.HostType.Name))
+}
+
+func (h *HostObject) Inspect() string {
+	if s, ok := h.Value.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return "<" + h.HostType.Name + ">"
+}
+
+func (h *HostObject) InvokeMethod(method string, env Environment, args ...Object) Object {
+	if fn, ok := h.HostType.Methods[method]; ok {
+		return fn(h, args...)
+	}
+	if prop, ok := h.HostType.Properties[method]; ok && len(args) == 0 {
+		return prop(h)
+	}
+	if method == "methods" {
+		static := []string{"methods"}
+		for name := range h.HostType.Methods {
+			static = append(static, name)
+		}
+		for name := range h.HostType.Properties {
+			static = append(static, name)
+		}
+		dynamic := env.Names(strings.ToLower(h.HostType.Name) + ".")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+// Property returns the named property, or nil if the type has none.
+func (h *HostObject) Property(name string) Object {
+	if prop, ok := h.HostType.Properties[name]; ok {
+		return prop(h)
+	}
+	return nil
+}
+
+func (h *HostObject) Reset() {
+	h.next = nil
+	if h.HostType.Iterate != nil {
+		h.next = h.HostType.Iterate(h)
+	}
+}
+
+func (h *HostObject) Next() (Object, Object, bool) {
+	if h.next == nil {
+		return nil, &Integer{Value: 0}, false
+	}
+	return h.next()
+}
+
+func (h *HostObject) HashKey() HashKey {
+	return HashKey{Type: h.Type(), Value: h.HostType.Hash(h)}
+}
+
+func (h *HostObject) ToInterface() interface{} {
+	return h.Value
+}
+
+// AsIterable returns obj as an Iterable if it can be used with foreach.
+// It should be preferred over a type assertion, since every host object
+// has the methods but only some host types support iteration.
+func AsIterable(obj Object) (Iterable, bool) {
+	if h, ok := obj.(*HostObject); ok {
+		return h, h.HostType.Iterate != nil
+	}
+	it, ok := obj.(Iterable)
+	return it, ok
+}
+
+// AsFreshIterable is AsIterable for a copy of obj with iteration state
+// of its own, so that tasks may loop over a shared value at the same
+// time.  Values such as generators and channels are consumed by
+// iterating over them, and are not copied.
+func AsFreshIterable(obj Object) (Iterable, bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		return AsIterable(&Array{Elements: obj.Elements})
+	case *Hash:
+		return AsIterable(&Hash{Pairs: obj.Pairs})
+	case *String:
+		return AsIterable(&String{Value: obj.Value})
+	case *Range:
+		return AsIterable(&Range{Start: obj.Start, End: obj.End, Step: obj.Step})
+	case *HostObject:
+		return AsIterable(&HostObject{HostType: obj.HostType, Value: obj.Value})
+	}
+	return AsIterable(obj)
+}
+
+// AsHashable returns obj as a Hashable if it can be used as a hash key.
+func AsHashable(obj Object) (Hashable, bool) {
+	if h, ok := obj.(*HostObject); ok {
+		return h, h.HostType.Hash != nil
+	}
+	key, ok := obj.(Hashable)
+	return key, ok
+}
@@ -0,0 +1,25 @@
+package object
+
+// LoopControl carries BREAK or CONTINUE out of the body of a loop, just
+// as ReturnValue carries RETURN out of a function.
+type LoopControl struct {
+	Continue bool
+}
+
+func (lc *LoopControl) Type() Type {
+	return LOOP_CONTROL_OBJ
+}
+func (lc *LoopControl) Inspect() string {
+	if lc.Continue {
+		return "CONTINUE"
+	}
+	return "BREAK"
+}
+
+func (lc *LoopControl) InvokeMethod(method string, env Environment, args ...Object) Object {
+	return nil
+}
+
+func (lc *LoopControl) ToInterface() interface{} {
+	return "<LOOP_CONTROL>"
+}
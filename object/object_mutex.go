@@ -0,0 +1,83 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Mutex lets tasks take turns.  It is built on a channel rather than a
+// sync.Mutex so that unlocking it when it is not locked is an error
+// rather than a crash.
+type Mutex struct {
+	held chan struct{}
+}
+
+func NewMutex() *Mutex {
+	return &Mutex{held: make(chan struct{}, 1)}
+}
+
+// Lock waits until the mutex is free, then takes it.
+func (m *Mutex) Lock() {
+	m.held <- struct{}{}
+}
+
+// Unlock frees the mutex.
+func (m *Mutex) Unlock() *Error {
+	select {
+	case <-m.held:
+		return nil
+	default:
+		return &Error{Message: "unlock of unlocked mutex"}
+	}
+}
+
+func (m *Mutex) Type() Type {
+	return MUTEX_OBJ
+}
+
+func (m *Mutex) Inspect() string {
+	return "<mutex>"
+}
+
+func (m *Mutex) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "lock":
+		m.Lock()
+		return &Null{}
+	case "unlock":
+		if err := m.Unlock(); err != nil {
+			return err
+		}
+		return &Null{}
+	case "with":
+		if len(args) < 1 {
+			return &Error{Message: fmt.Sprintf("wrong number of arguments to `with`. got=%d, want=1+", len(args))}
+		}
+		m.Lock()
+		defer m.Unlock()
+		return Apply(&env, args[0], args[1:]...)
+	case "methods":
+		static := []string{"lock", "methods", "unlock", "with"}
+		dynamic := env.Names("mutex.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+func (m *Mutex) ToInterface() interface{} {
+	return "<MUTEX>"
+}
@@ -0,0 +1,101 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Pool runs calls concurrently, but no more than Size at once however
+// many calls are made through it.
+type Pool struct {
+	Size int
+
+	slots chan struct{}
+}
+
+func NewPool(size int) *Pool {
+	return &Pool{Size: size, slots: make(chan struct{}, size)}
+}
+
+// Map calls fn with each value produced by it, returning the results in
+// order, or wrapping the first error in that order.
+func (p *Pool) Map(env *Environment, fn Object, it Iterable) Object {
+	var values []Object
+	it.Reset()
+	for val, _, ok := it.Next(); ok; val, _, ok = it.Next() {
+		values = append(values, val)
+	}
+
+	results := make([]Object, len(values))
+	var wg sync.WaitGroup
+	for i, val := range values {
+		wg.Add(1)
+		p.slots <- struct{}{}
+		go func(i int, val Object) {
+			defer func() {
+				<-p.slots
+				wg.Done()
+			}()
+			// Only kept if exit() ends the goroutine without Apply
+			// returning.
+			results[i] = &Error{Message: "call ended by exit()"}
+			results[i] = Apply(env, fn, val)
+		}(i, val)
+	}
+	wg.Wait()
+
+	for i, res := range results {
+		if err, ok := res.(*Error); ok && !err.Held {
+			return Wrap(err, "map failed for %s", values[i].Inspect())
+		}
+	}
+	return &Array{Elements: results}
+}
+
+func (p *Pool) Type() Type {
+	return POOL_OBJ
+}
+
+func (p *Pool) Inspect() string {
+	return fmt.Sprintf("<pool %d>", p.Size)
+}
+
+func (p *Pool) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "map":
+		if len(args) != 2 {
+			return &Error{Message: fmt.Sprintf("wrong number of arguments to `map`. got=%d, want=2", len(args))}
+		}
+		it, ok := AsFreshIterable(args[1])
+		if !ok {
+			return &Error{Message: fmt.Sprintf("argument to `map` must be iterable, got=%s", args[1].Type())}
+		}
+		return p.Map(&env, args[0], it)
+	case "size":
+		return &Integer{Value: int64(p.Size)}
+	case "methods":
+		static := []string{"map", "methods", "size"}
+		dynamic := env.Names("pool.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+func (p *Pool) ToInterface() interface{} {
+	return "<POOL>"
+}
@@ -0,0 +1,114 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Range is the sequence of integers produced by A..B, counting down
+// when B is less than A.  Its elements are computed as they are needed
+// rather than held in memory.
+type Range struct {
+	Start int64
+	End   int64
+
+	// Step is the distance between elements, which is always positive
+	// whichever way the range runs.
+	Step int64
+
+	offset int64
+}
+
+func (r *Range) Type() Type {
+	return RANGE_OBJ
+}
+
+func (r *Range) Inspect() string {
+	if r.Step != 1 {
+		return fmt.Sprintf("%d..%d step %d", r.Start, r.End, r.Step)
+	}
+	return fmt.Sprintf("%d..%d", r.Start, r.End)
+}
+
+// Len returns the number of elements in the range.
+func (r *Range) Len() int64 {
+	if r.End >= r.Start {
+		return int64((uint64(r.End)-uint64(r.Start))/uint64(r.Step)) + 1
+	}
+	return int64((uint64(r.Start)-uint64(r.End))/uint64(r.Step)) + 1
+}
+
+// At returns the element at index i, which must be less than Len.
+func (r *Range) At(i int64) int64 {
+	if r.End >= r.Start {
+		return r.Start + i*r.Step
+	}
+	return r.Start - i*r.Step
+}
+
+// Contains reports whether n is an element of the range.
+func (r *Range) Contains(n int64) bool {
+	if r.End >= r.Start {
+		return n >= r.Start && n <= r.End && (n-r.Start)%r.Step == 0
+	}
+	return n <= r.Start && n >= r.End && (r.Start-n)%r.Step == 0
+}
+
+// ToArray returns the elements of the range as an array.
+func (r *Range) ToArray() *Array {
+	elements := make([]Object, r.Len())
+	for i := range elements {
+		elements[i] = &Integer{Value: r.At(int64(i))}
+	}
+	return &Array{Elements: elements}
+}
+
+func (r *Range) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "len":
+		return &Integer{Value: r.Len()}
+	case "contains":
+		if len(args) != 1 {
+			return &Error{Message: fmt.Sprintf("wrong number of arguments to `contains`. got=%d, want=1", len(args))}
+		}
+		n, ok := args[0].(*Integer)
+		return &Boolean{Value: ok && r.Contains(n.Value)}
+	case "to_array":
+		return r.ToArray()
+	case "methods":
+		static := []string{"contains", "len", "methods", "to_array"}
+		dynamic := append(env.Names("range."), env.Names("array.")...)
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+func (r *Range) Reset() {
+	r.offset = 0
+}
+
+func (r *Range) Next() (Object, Object, bool) {
+	if r.offset < r.Len() {
+		r.offset++
+		return &Integer{Value: r.At(r.offset - 1)}, &Integer{Value: r.offset - 1}, true
+	}
+	return nil, &Integer{Value: 0}, false
+}
+
+func (r *Range) ToInterface() interface{} {
+	return "<RANGE>"
+}
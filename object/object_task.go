@@ -0,0 +1,80 @@
+package object
+
+import (
+	"sort"
+	"strings"
+)
+
+// Task is a function call running concurrently with the script, as
+// started by spawn().
+type Task struct {
+	done   chan struct{}
+	result Object
+}
+
+// NewTask starts run in a goroutine of its own.
+func NewTask(run func() Object) *Task {
+	t := &Task{done: make(chan struct{})}
+	go func() {
+		defer close(t.done)
+		// Only kept if exit() ends the goroutine without run returning.
+		t.result = &Error{Message: "task ended by exit()"}
+		t.result = run()
+	}()
+	return t
+}
+
+// Wait blocks until the task has finished, returning its result.
+func (t *Task) Wait() Object {
+	<-t.done
+	return t.result
+}
+
+// Done reports whether the task has finished.
+func (t *Task) Done() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *Task) Type() Type {
+	return TASK_OBJ
+}
+
+func (t *Task) Inspect() string {
+	return "<task>"
+}
+
+func (t *Task) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "wait":
+		return t.Wait()
+	case "done":
+		return &Boolean{Value: t.Done()}
+	case "methods":
+		static := []string{"done", "methods", "wait"}
+		dynamic := env.Names("task.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+func (t *Task) ToInterface() interface{} {
+	return "<TASK>"
+}
@@ -1,6 +1,11 @@
 package object
 
-import "testing"
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -17,3 +22,225 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("string with different have same hash key")
 	}
 }
+
+type point struct {
+	X      int64
+	Y      int64  `scream:"y"`
+	Label  string `scream:"-"`
+	hidden bool
+}
+
+func TestFromGo(t *testing.T) {
+	obj, err := FromGo(map[string]interface{}{
+		"list":  []int{1, 2, 3},
+		"pi":    3.5,
+		"ok":    true,
+		"none":  nil,
+		"point": &point{X: 1, Y: 2, Label: "skipped"},
+	})
+	if err != nil {
+		t.Fatalf("FromGo failed: %s", err)
+	}
+	hash, ok := obj.(*Hash)
+	if !ok {
+		t.Fatalf("FromGo gave %s, want HASH", obj.Type())
+	}
+
+	get := func(h *Hash, key string) Object {
+		return h.Pairs[(&String{Value: key}).HashKey()].Value
+	}
+	if got := get(hash, "list").Inspect(); got != "[1, 2, 3]" {
+		t.Errorf("list is %s", got)
+	}
+	if got := get(hash, "pi").Type(); got != FLOAT_OBJ {
+		t.Errorf("pi is %s", got)
+	}
+	if got := get(hash, "none").Type(); got != NULL_OBJ {
+		t.Errorf("none is %s", got)
+	}
+	p := get(hash, "point").(*Hash)
+	if len(p.Pairs) != 2 || get(p, "X").Inspect() != "1" || get(p, "y").Inspect() != "2" {
+		t.Errorf("point is %s", p.Inspect())
+	}
+
+	if _, err := FromGo(make(chan int)); err == nil {
+		t.Errorf("FromGo accepted a channel")
+	}
+}
+
+func TestToGoRoundTrip(t *testing.T) {
+	in := point{X: 3, Y: -4}
+	obj, err := FromGo(in)
+	if err != nil {
+		t.Fatalf("FromGo failed: %s", err)
+	}
+	v, err := ToGoValue(obj, reflect.TypeOf(point{}))
+	if err != nil {
+		t.Fatalf("ToGoValue failed: %s", err)
+	}
+	if out := v.Interface().(point); out != in {
+		t.Errorf("round trip gave %+v, want %+v", out, in)
+	}
+
+	plain := ToGo(obj).(map[string]interface{})
+	if plain["X"] != int64(3) || plain["y"] != int64(-4) {
+		t.Errorf("ToGo gave %v", plain)
+	}
+
+	if _, err := ToGoValue(&Integer{Value: 300}, reflect.TypeOf(int8(0))); err == nil {
+		t.Errorf("ToGoValue did not catch an overflow")
+	}
+}
+
+func TestHostType(t *testing.T) {
+	type handle struct{ name string }
+	ht := &HostType{
+		Name:   "handle",
+		GoType: reflect.TypeOf(&handle{}),
+		Properties: map[string]func(*HostObject) Object{
+			"name": func(h *HostObject) Object { return &String{Value: h.Value.(*handle).name} },
+		},
+	}
+	if err := RegisterType(ht); err != nil {
+		t.Fatalf("RegisterType failed: %s", err)
+	}
+	if err := RegisterType(&HostType{Name: "handle"}); err == nil {
+		t.Errorf("registering a duplicate name succeeded")
+	}
+
+	obj, err := FromGo(&handle{name: "db"})
+	if err != nil {
+		t.Fatalf("FromGo failed: %s", err)
+	}
+	h, ok := obj.(*HostObject)
+	if !ok {
+		t.Fatalf("FromGo gave %s, want a host object", obj.Type())
+	}
+	if got := h.InvokeMethod("name", *NewEnvironment()).Inspect(); got != "db" {
+		t.Errorf("name property is %s", got)
+	}
+	if _, ok := AsIterable(h); ok {
+		t.Errorf("host object without Iterate is iterable")
+	}
+	if _, ok := AsHashable(h); ok {
+		t.Errorf("host object without Hash is hashable")
+	}
+}
+
+func TestRange(t *testing.T) {
+	tests := []struct {
+		r    *Range
+		want string
+	}{
+		{&Range{Start: 1, End: 5, Step: 1}, "[1, 2, 3, 4, 5]"},
+		{&Range{Start: 1, End: 10, Step: 4}, "[1, 5, 9]"},
+		{&Range{Start: 10, End: 1, Step: 3}, "[10, 7, 4, 1]"},
+		{&Range{Start: 3, End: 3, Step: 1}, "[3]"},
+	}
+	for _, tt := range tests {
+		if got := tt.r.ToArray().Inspect(); got != tt.want {
+			t.Errorf("%s gave %s, want %s", tt.r.Inspect(), got, tt.want)
+		}
+		if n := tt.r.Len(); n != int64(len(tt.r.ToArray().Elements)) {
+			t.Errorf("%s has length %d", tt.r.Inspect(), n)
+		}
+
+		var seen []Object
+		tt.r.Reset()
+		for val, _, ok := tt.r.Next(); ok; val, _, ok = tt.r.Next() {
+			seen = append(seen, val)
+			if !tt.r.Contains(val.(*Integer).Value) {
+				t.Errorf("%s does not contain its element %s", tt.r.Inspect(), val.Inspect())
+			}
+		}
+		if got := (&Array{Elements: seen}).Inspect(); got != tt.want {
+			t.Errorf("iterating %s gave %s, want %s", tt.r.Inspect(), got, tt.want)
+		}
+	}
+
+	r := &Range{Start: 10, End: 1, Step: 3}
+	for _, n := range []int64{0, 2, 11, 8} {
+		if r.Contains(n) {
+			t.Errorf("%s contains %d", r.Inspect(), n)
+		}
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	naturals := func() *Generator {
+		return NewGenerator("naturals", func(yield func(Object)) Object {
+			for i := int64(1); ; i++ {
+				yield(&Integer{Value: i})
+			}
+		})
+	}
+
+	g := naturals()
+	for want := int64(1); want <= 3; want++ {
+		val, idx, ok := g.Next()
+		if !ok || val.(*Integer).Value != want || idx.(*Integer).Value != want-1 {
+			t.Fatalf("got %v at %v, want %d", val, idx, want)
+		}
+	}
+	g.Close()
+	if _, _, ok := g.Next(); ok {
+		t.Errorf("closed generator produced a value")
+	}
+
+	finite := NewGenerator("", func(yield func(Object)) Object {
+		yield(&String{Value: "only"})
+		return &Error{Message: "failed"}
+	})
+	if val, _, ok := finite.Next(); !ok || val.Inspect() != "only" {
+		t.Fatalf("got %v, want only", val)
+	}
+	if _, _, ok := finite.Next(); ok {
+		t.Fatalf("finished generator produced a value")
+	}
+	if finite.Err() == nil || finite.Err().Message != "failed" {
+		t.Errorf("error was not kept, got %v", finite.Err())
+	}
+
+	// Closing before starting never starts the body.
+	naturals().Close()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines leaked", n-before)
+	}
+}
+
+func TestChannel(t *testing.T) {
+	c := NewChannel(1)
+	task := NewTask(func() Object {
+		for i := int64(1); i <= 3; i++ {
+			if err := c.Send(&Integer{Value: i}); err != nil {
+				return err
+			}
+		}
+		c.Close()
+		return &String{Value: "sent"}
+	})
+
+	var sum int64
+	for val, _, ok := c.Next(); ok; val, _, ok = c.Next() {
+		sum += val.(*Integer).Value
+	}
+	if sum != 6 {
+		t.Errorf("received %d, want 6", sum)
+	}
+	if res := task.Wait(); res.Inspect() != "sent" || !task.Done() {
+		t.Errorf("task gave %s", res.Inspect())
+	}
+	if err := c.Send(&Null{}); err == nil {
+		t.Errorf("send on closed channel succeeded")
+	}
+	if err := c.Close(); err == nil {
+		t.Errorf("second close succeeded")
+	}
+}
@@ -0,0 +1,81 @@
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// WaitGroup waits for a number of tasks to finish.
+type WaitGroup struct {
+	wg sync.WaitGroup
+}
+
+// Add adds delta to the number of tasks being waited for.  It fails if
+// the number would become negative.
+func (w *WaitGroup) Add(delta int) (err *Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &Error{Message: fmt.Sprint(r)}
+		}
+	}()
+	w.wg.Add(delta)
+	return nil
+}
+
+func (w *WaitGroup) Type() Type {
+	return WAITGROUP_OBJ
+}
+
+func (w *WaitGroup) Inspect() string {
+	return "<waitgroup>"
+}
+
+func (w *WaitGroup) InvokeMethod(method string, env Environment, args ...Object) Object {
+	switch method {
+	case "add":
+		delta := int64(1)
+		if len(args) > 0 {
+			n, ok := args[0].(*Integer)
+			if !ok {
+				return &Error{Message: fmt.Sprintf("argument to `add` must be INTEGER, got=%s", args[0].Type())}
+			}
+			delta = n.Value
+		}
+		if err := w.Add(int(delta)); err != nil {
+			return err
+		}
+		return &Null{}
+	case "done":
+		if err := w.Add(-1); err != nil {
+			return err
+		}
+		return &Null{}
+	case "wait":
+		w.wg.Wait()
+		return &Null{}
+	case "methods":
+		static := []string{"add", "done", "methods", "wait"}
+		dynamic := env.Names("waitgroup.")
+
+		var names []string
+		names = append(names, static...)
+		for _, e := range dynamic {
+			bits := strings.Split(e, ".")
+			names = append(names, bits[1])
+		}
+		sort.Strings(names)
+
+		result := make([]Object, len(names))
+		for i, txt := range names {
+			result[i] = &String{Value: txt}
+		}
+		return &Array{Elements: result}
+	}
+	return nil
+}
+
+func (w *WaitGroup) ToInterface() interface{} {
+	return "<WAITGROUP>"
+}
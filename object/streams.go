@@ -0,0 +1,59 @@
+package object
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+)
+
+// Streams are the standard input, output and error used by a running
+// script.  Output is buffered, so it must be flushed before the program
+// exits or the captured output is read.  Print, Flush and Diagnose may
+// be used by tasks running concurrently.
+type Streams struct {
+	Stdin  *bufio.Reader
+	Stdout *bufio.Writer
+	Stderr *bufio.Writer
+
+	mu sync.Mutex
+}
+
+// Stdio is used by environments which have not been given streams of
+// their own.
+var Stdio = NewStreams(os.Stdin, os.Stdout, os.Stderr)
+
+func NewStreams(in io.Reader, out io.Writer, err io.Writer) *Streams {
+	return &Streams{
+		Stdin:  bufio.NewReader(in),
+		Stdout: bufio.NewWriter(out),
+		Stderr: bufio.NewWriter(err),
+	}
+}
+
+// Print writes text to the output stream.
+func (s *Streams) Print(text string) {
+	s.mu.Lock()
+	s.Stdout.WriteString(text)
+	s.mu.Unlock()
+}
+
+// Flush writes out any buffered output.
+func (s *Streams) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.Stdout.Flush(); err != nil {
+		return err
+	}
+	return s.Stderr.Flush()
+}
+
+// Diagnose writes a message to the error stream, flushing the output
+// stream first so the two appear in order on a terminal.
+func (s *Streams) Diagnose(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Stdout.Flush()
+	s.Stderr.WriteString(msg)
+	s.Stderr.Flush()
+}
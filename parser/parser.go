@@ -90,11 +90,13 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.Type]prefixParseFn)
 	p.registerPrefix(token.BACKTICK, p.parseBacktickLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
+	p.registerPrefix(token.ELLIPSIS, p.parseSpreadExpression)
 	p.registerPrefix(token.DEFINE_FUNCTION, p.parseFunctionDefinition)
 	p.registerPrefix(token.EOF, p.parsingBroken)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.FOR, p.parseForLoopExpression)
+	p.registerPrefix(token.COUNTED_FOR, p.parseCountedForExpression)
 	p.registerPrefix(token.FOREACH, p.parseForEach)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
@@ -118,7 +120,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK_EQUALS, p.parseAssignExpression)
 	p.registerInfix(token.CONTAINS, p.parseInfixExpression)
-	p.registerInfix(token.DOTDOT, p.parseInfixExpression)
+	p.registerInfix(token.DOTDOT, p.parseRangeExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.GT_EQUALS, p.parseInfixExpression)
@@ -188,11 +190,24 @@ func (p *Parser) ParseProgram() *ast.Program {
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
+		if p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE) {
+			return p.parseDestructuringStatement()
+		}
 		return p.parseLetStatement()
 	case token.CONST:
 		return p.parseConstStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.YIELD:
+		return p.parseYieldStatement()
+	case token.DEFER:
+		return p.parseDeferStatement()
+	case token.BEGIN_INPUT, token.END_INPUT:
+		return p.parseInputBlock()
+	case token.BREAK:
+		return p.parseLoopControl(&ast.BreakStatement{Token: p.curToken})
+	case token.CONTINUE:
+		return p.parseLoopControl(&ast.ContinueStatement{Token: p.curToken})
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -222,6 +237,150 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+func (p *Parser) parseDestructuringStatement() *ast.DestructuringStatement {
+	stmt := &ast.DestructuringStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Pattern = p.parsePattern()
+	if stmt.Pattern == nil {
+		return nil
+	}
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	for !p.curTokenIs(token.SEMICOLON) {
+
+		if p.curTokenIs(token.EOF) {
+			p.errors = append(p.errors, "unterminated let statement")
+			return nil
+		}
+
+		p.nextToken()
+	}
+	return stmt
+}
+
+// parsePattern parses the array or hash pattern starting at the current
+// token.
+func (p *Parser) parsePattern() ast.Expression {
+	switch p.curToken.Type {
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	}
+	p.errors = append(p.errors, fmt.Sprintf("expected [ or { to start a pattern, got %s around line %d", p.curToken.Literal, p.l.GetLine()))
+	return nil
+}
+
+func (p *Parser) parseArrayPattern() ast.Expression {
+	pat := &ast.ArrayPattern{Token: p.curToken, Defaults: make(map[string]ast.Expression)}
+	if p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+		return pat
+	}
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.ELLIPSIS) {
+			if pat.Rest = p.parseRestTarget(token.RBRACKET); pat.Rest == nil {
+				return nil
+			}
+			return pat
+		}
+		target := p.parsePatternTarget(pat.Defaults)
+		if target == nil {
+			return nil
+		}
+		pat.Elements = append(pat.Elements, target)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	return pat
+}
+
+func (p *Parser) parseHashPattern() ast.Expression {
+	pat := &ast.HashPattern{Token: p.curToken, Defaults: make(map[string]ast.Expression)}
+	if p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		return pat
+	}
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.ELLIPSIS) {
+			if pat.Rest = p.parseRestTarget(token.RBRACE); pat.Rest == nil {
+				return nil
+			}
+			return pat
+		}
+		if !p.curTokenIs(token.IDENT) && !p.curTokenIs(token.STRING) {
+			p.errors = append(p.errors, fmt.Sprintf("expected a key in hash pattern, got %s around line %d", p.curToken.Literal, p.l.GetLine()))
+			return nil
+		}
+		key := p.curToken.Literal
+		var target ast.Expression
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			p.nextToken()
+			target = p.parsePatternTarget(pat.Defaults)
+		} else if p.curTokenIs(token.IDENT) {
+			target = p.parsePatternTarget(pat.Defaults)
+		} else {
+			p.errors = append(p.errors, fmt.Sprintf("expected : after %q in hash pattern around line %d", key, p.l.GetLine()))
+		}
+		if target == nil {
+			return nil
+		}
+		pat.Keys = append(pat.Keys, key)
+		pat.Targets = append(pat.Targets, target)
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+	return pat
+}
+
+// parsePatternTarget parses what part of a pattern is bound to: an
+// identifier, with an optional default, or a nested pattern.
+func (p *Parser) parsePatternTarget(defaults map[string]ast.Expression) ast.Expression {
+	switch p.curToken.Type {
+	case token.LBRACKET, token.LBRACE:
+		return p.parsePattern()
+	case token.IDENT:
+	default:
+		return p.parseExpression(LOWEST)
+	}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if p.peekTokenIs(token.ASSIGN) {
+		p.nextToken()
+		p.nextToken()
+		defaults[ident.Value] = p.parseExpression(LOWEST)
+	}
+	return ident
+}
+
+// parseRestTarget parses the name following "..." in a pattern, which
+// must come last.
+func (p *Parser) parseRestTarget(end token.Type) *ast.Identifier {
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(end) {
+		return nil
+	}
+	return ident
+}
+
 func (p *Parser) parseConstStatement() *ast.ConstStatement {
 	stmt := &ast.ConstStatement{Token: p.curToken}
 	if !p.expectPeek(token.IDENT) {
@@ -261,6 +420,38 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+func (p *Parser) parseYieldStatement() *ast.YieldStatement {
+	stmt := &ast.YieldStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	for !p.curTokenIs(token.SEMICOLON) {
+
+		if p.curTokenIs(token.EOF) {
+			p.errors = append(p.errors, "unterminated yield statement")
+			return nil
+		}
+
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseDeferStatement() *ast.DeferStatement {
+	stmt := &ast.DeferStatement{Token: p.curToken}
+	p.nextToken()
+	stmt.Call = p.parseExpression(LOWEST)
+	for !p.curTokenIs(token.SEMICOLON) {
+
+		if p.curTokenIs(token.EOF) {
+			p.errors = append(p.errors, "unterminated defer statement")
+			return nil
+		}
+
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) noPrefixParseFnError(t token.Type) {
 	msg := fmt.Sprintf("no prefix parse function for %s found around line %d", t, p.l.GetLine())
 	p.errors = append(p.errors, msg)
@@ -368,13 +559,18 @@ func (p *Parser) parseSwitchStatement() ast.Expression {
 			if p.curTokenIs(token.DEFAULT) {
 				tmp.Default = true
 			} else {
-				tmp.Expr = append(tmp.Expr, p.parseExpression(LOWEST))
+				tmp.Expr = append(tmp.Expr, p.parseCasePattern())
 				for p.peekTokenIs(token.COMMA) {
 					p.nextToken()
 
 					p.nextToken()
 
-					tmp.Expr = append(tmp.Expr, p.parseExpression(LOWEST))
+					tmp.Expr = append(tmp.Expr, p.parseCasePattern())
+				}
+				if p.peekIsGuard() {
+					p.nextToken()
+					p.nextToken()
+					tmp.Guard = p.parseExpression(LOWEST)
 				}
 			}
 		} else {
@@ -419,6 +615,40 @@ func (p *Parser) parseSwitchStatement() ast.Expression {
 
 }
 
+// typePatterns are the names of types a switch case may match, as
+// reported by type().
+var typePatterns = map[string]bool{
+	"array": true, "atomic": true, "bool": true, "builtin": true,
+	"channel": true, "error": true, "file": true, "float": true,
+	"function": true, "generator": true, "hash": true, "integer": true,
+	"mutex": true, "null": true, "pool": true, "range": true,
+	"regexp": true, "string": true, "task": true, "waitgroup": true,
+}
+
+// parseCasePattern parses one of the comma-separated values of a case.
+// Besides any expression, whose value is compared with the switch's,
+// it may be an array or hash pattern, the name of a type, or a name
+// followed by a guard, which binds whatever value it is given.
+func (p *Parser) parseCasePattern() ast.Expression {
+	switch {
+	case p.curTokenIs(token.LBRACKET), p.curTokenIs(token.LBRACE):
+		return p.parsePattern()
+	case p.curTokenIs(token.IDENT) && typePatterns[p.curToken.Literal] &&
+		(p.peekTokenIs(token.COMMA) || p.peekTokenIs(token.LBRACE) || p.peekIsGuard()):
+		return &ast.TypePattern{Token: p.curToken, Name: p.curToken.Literal}
+	case p.curTokenIs(token.IDENT) && p.peekIsGuard():
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		return &ast.VariablePattern{Token: p.curToken, Name: ident}
+	}
+	return p.parseExpression(LOWEST)
+}
+
+// peekIsGuard reports whether the next token starts the guard of a case,
+// which may be written "if" or "IF".
+func (p *Parser) peekIsGuard() bool {
+	return p.peekTokenIs(token.IF) || p.peekTokenIs(token.IDENT) && p.peekToken.Literal == "if"
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -456,6 +686,24 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseRangeExpression parses A..B, along with any "step" after it.
+// step is only special here, so it may still name a variable.
+func (p *Parser) parseRangeExpression(left ast.Expression) ast.Expression {
+	expression := p.parseInfixExpression(left)
+	if !p.peekTokenIs(token.IDENT) || p.peekToken.Literal != "step" {
+		return expression
+	}
+	p.nextToken()
+	step := &ast.InfixExpression{
+		Token:    token.Token{Type: token.STEP, Literal: p.curToken.Literal, Line: p.curToken.Line, Column: p.curToken.Column},
+		Operator: p.curToken.Literal,
+		Left:     expression,
+	}
+	p.nextToken()
+	step.Right = p.parseExpression(DOTDOT)
+	return step
+}
+
 func (p *Parser) parseTernaryExpression(condition ast.Expression) ast.Expression {
 	if p.tern {
 		msg := fmt.Sprintf("nested ternary expressions are illegal, around line %d", p.l.GetLine())
@@ -590,23 +838,93 @@ func (p *Parser) parseForLoopExpression() ast.Expression {
 	return expression
 }
 
-func (p *Parser) parseForEach() ast.Expression {
-	expression := &ast.ForeachStatement{Token: p.curToken}
+// parseCountedForExpression parses FOR (init; condition; post) { body },
+// where any of init, condition and post may be left out.
+func (p *Parser) parseCountedForExpression() ast.Expression {
+	expression := &ast.CountedForExpression{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
 
 	p.nextToken()
-	expression.Ident = p.curToken.Literal
+	switch {
+	case p.curTokenIs(token.LET):
+		let := p.parseLetStatement()
+		if let == nil {
+			return nil
+		}
+		expression.Init = let
+	case !p.curTokenIs(token.SEMICOLON):
+		stmt := &ast.ExpressionStatement{Token: p.curToken}
+		stmt.Expression = p.parseExpression(LOWEST)
+		expression.Init = stmt
+		if !p.expectPeek(token.SEMICOLON) {
+			return nil
+		}
+	}
 
-	if p.peekTokenIs(token.COMMA) {
+	if !p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
+		expression.Condition = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
 
-		if !p.peekTokenIs(token.IDENT) {
-			p.errors = append(p.errors, fmt.Sprintf("second argument to foreach must be ident, got %v", p.peekToken))
+	if !p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		// The postfix operator of I++ is parsed on its own, taking
+		// its operand from the previous token.
+		if p.peekTokenIs(token.PLUS_PLUS) || p.peekTokenIs(token.MINUS_MINUS) {
+			p.nextToken()
+		}
+		expression.Post = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	expression.Body = p.parseBlockStatement()
+	if expression.Body == nil {
+		return nil
+	}
+	return expression
+}
+
+func (p *Parser) parseForEach() ast.Expression {
+	expression := &ast.ForeachStatement{Token: p.curToken}
+
+	p.nextToken()
+	if p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE) {
+		if expression.Pattern = p.parsePattern(); expression.Pattern == nil {
 			return nil
 		}
+	} else {
+		expression.Ident = p.curToken.Literal
+	}
+
+	if p.peekTokenIs(token.COMMA) && expression.Pattern == nil {
 		p.nextToken()
 
-		expression.Index = expression.Ident
-		expression.Ident = p.curToken.Literal
+		switch {
+		case p.peekTokenIs(token.IDENT):
+			p.nextToken()
+			expression.Index = expression.Ident
+			expression.Ident = p.curToken.Literal
+		case p.peekTokenIs(token.LBRACKET) || p.peekTokenIs(token.LBRACE):
+			p.nextToken()
+			expression.Index = expression.Ident
+			expression.Ident = ""
+			if expression.Pattern = p.parsePattern(); expression.Pattern == nil {
+				return nil
+			}
+		default:
+			p.errors = append(p.errors, fmt.Sprintf("second argument to foreach must be ident or pattern, got %v", p.peekToken))
+			return nil
+		}
 
 	}
 	if !p.expectPeek(token.IN) {
@@ -624,6 +942,25 @@ func (p *Parser) parseForEach() ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseLoopControl(stmt ast.Statement) ast.Statement {
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseInputBlock() ast.Statement {
+	stmt := &ast.InputBlock{Token: p.curToken}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseBlockStatement()
+	if stmt.Body == nil {
+		return nil
+	}
+	return stmt
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 
 	block := &ast.BlockStatement{Token: p.curToken}
@@ -652,11 +989,12 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
-	lit.Defaults, lit.Parameters = p.parseFunctionParameters()
+	lit.Defaults, lit.Parameters, lit.Variadic, lit.Patterns = p.parseFunctionParameters()
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 	lit.Body = p.parseBlockStatement()
+	lit.Generator = yields(lit.Body)
 	return lit
 }
 
@@ -667,24 +1005,32 @@ func (p *Parser) parseFunctionDefinition() ast.Expression {
 		return nil
 	}
 
-	lit.Defaults, lit.Parameters = p.parseFunctionParameters()
+	lit.Defaults, lit.Parameters, lit.Variadic, lit.Patterns = p.parseFunctionParameters()
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 	lit.Body = p.parseBlockStatement()
+	lit.Generator = yields(lit.Body)
 
 	return lit
 }
-func (p *Parser) parseFunctionParameters() (map[string]ast.Expression, []*ast.Identifier) {
+
+// parseFunctionParameters returns the defaults and names of a function's
+// parameters, whether the last of them is a rest parameter, and the
+// patterns of those which destructure their argument.  Such parameters
+// are named after their pattern, which no script can refer to.
+func (p *Parser) parseFunctionParameters() (map[string]ast.Expression, []*ast.Identifier, bool, map[string]ast.Expression) {
 
 	m := make(map[string]ast.Expression)
 
 	identifiers := make([]*ast.Identifier, 0)
 
+	patterns := make(map[string]ast.Expression)
+
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return m, identifiers
+		return m, identifiers, false, patterns
 	}
 	p.nextToken()
 
@@ -692,9 +1038,29 @@ func (p *Parser) parseFunctionParameters() (map[string]ast.Expression, []*ast.Id
 
 		if p.curTokenIs(token.EOF) {
 			p.errors = append(p.errors, "unterminated function parameters")
-			return nil, nil
+			return nil, nil, false, nil
+		}
+		if p.curTokenIs(token.ELLIPSIS) {
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil, false, nil
+			}
+			identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+			if !p.peekTokenIs(token.RPAREN) {
+				p.errors = append(p.errors, fmt.Sprintf("rest parameter %s must be the last parameter around line %d", p.curToken.Literal, p.l.GetLine()))
+				return nil, nil, false, nil
+			}
+			p.nextToken()
+			return m, identifiers, true, patterns
 		}
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE) {
+			pattern := p.parsePattern()
+			if pattern == nil {
+				return nil, nil, false, nil
+			}
+			ident.Value = pattern.String()
+			patterns[ident.Value] = pattern
+		}
 		identifiers = append(identifiers, ident)
 		p.nextToken()
 		if p.curTokenIs(token.ASSIGN) {
@@ -707,7 +1073,7 @@ func (p *Parser) parseFunctionParameters() (map[string]ast.Expression, []*ast.Id
 		}
 	}
 
-	return m, identifiers
+	return m, identifiers, false, patterns
 }
 
 func (p *Parser) parseStringLiteral() ast.Expression {
@@ -805,7 +1171,50 @@ func (p *Parser) parseAssignExpression(name ast.Expression) ast.Expression {
 }
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Arguments = p.parseCallArguments()
+	return exp
+}
+
+// parseCallArguments parses the arguments of a call, which are like
+// the elements of an array except that any given by keyword, as
+// "NAME: value", must follow those given by position.
+func (p *Parser) parseCallArguments() []ast.Expression {
+	list := make([]ast.Expression, 0)
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return list
+	}
+	keywords := false
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			arg := &ast.KeywordArgument{Token: p.curToken, Name: p.curToken.Literal}
+			p.nextToken()
+			p.nextToken()
+			arg.Value = p.parseExpression(LOWEST)
+			list = append(list, arg)
+			keywords = true
+		} else {
+			if keywords {
+				p.errors = append(p.errors, fmt.Sprintf("positional argument follows keyword argument around line %d", p.l.GetLine()))
+			}
+			list = append(list, p.parseExpression(LOWEST))
+		}
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return list
+}
+
+func (p *Parser) parseSpreadExpression() ast.Expression {
+	exp := &ast.SpreadExpression{Token: p.curToken}
+	p.nextToken()
+	exp.Value = p.parseExpression(PREFIX)
 	return exp
 }
 
@@ -871,3 +1280,19 @@ func (p *Parser) curPrecedence() int {
 	}
 	return LOWEST
 }
+
+// yields reports whether a function body contains YIELD, outside of any
+// function nested within it, making the function a generator.
+func yields(body *ast.BlockStatement) bool {
+	found := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.YieldStatement:
+			found = true
+		case *ast.FunctionLiteral, *ast.FunctionDefineLiteral:
+			return false
+		}
+		return !found
+	})
+	return found
+}
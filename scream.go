@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"scream/ast"
+	"scream/bundle"
+	"scream/cache"
 	"scream/evaluator"
 	"scream/lexer"
 	"scream/object"
@@ -16,22 +20,26 @@ var version = "master/unreleased"
 
 var stdlib string
 
+var optimize bool
+
 func versionFun(args ...object.Object) object.Object {
 	return &object.String{Value: version}
 }
 
+// scriptArgs are the arguments given to the script, returned by args().
+var scriptArgs []string
+
 func argsFun(args ...object.Object) object.Object {
-	l := len(os.Args[1:])
+	l := len(scriptArgs)
 	result := make([]object.Object, l)
-	for i, txt := range os.Args[1:] {
+	for i, txt := range scriptArgs {
 		result[i] = &object.String{Value: txt}
 	}
 	return &object.Array{Elements: result}
 }
 
-func Execute(input string) int {
-
-	env := object.NewEnvironment()
+// Parse parses input, exiting after reporting any errors.
+func Parse(input string) *ast.Program {
 	l := lexer.New(input)
 	p := parser.New(l)
 
@@ -40,7 +48,43 @@ func Execute(input string) int {
 		for _, msg := range p.Errors() {
 			fmt.Printf("\t%s\n", msg)
 		}
-		os.Exit(1)
+		object.Exit(1)
+	}
+	return program
+}
+
+func Execute(input string) int {
+	return Run(Parse(input))
+}
+
+// Run evaluates a parsed program.
+func Run(program *ast.Program) int {
+	env := newEnvironment()
+	prepare(program, env)
+
+	res := evaluator.Eval(program, env)
+	main := mainFunction(program)
+	if main != nil && !isError(res) {
+		res = callMain(env)
+	}
+	env.Streams().Flush()
+
+	if isError(res) {
+		return 1
+	}
+	if status, ok := res.(*object.Integer); ok && main != nil {
+		return int(status.Value)
+	}
+	return 0
+}
+
+// newEnvironment returns a global environment holding our builtins and
+// everything the prelude defines.
+func newEnvironment() *object.Environment {
+	env := object.NewEnvironment()
+	if running != nil {
+		env.SetExit(running.exit)
+		env.SetStreams(running.streams)
 	}
 
 	evaluator.RegisterBuiltin("version",
@@ -57,40 +101,233 @@ func Execute(input string) int {
 	initP := parser.New(initL)
 	initProg := initP.ParseProgram()
 	evaluator.Eval(initProg, env)
+	return env
+}
+
+// prepare readies program for evaluation in env, exiting after reporting
+// any names it uses which env does not define.
+func prepare(program *ast.Program, env *object.Environment) {
+	evaluator.BindLocation(program, evaluator.ScriptPath)
+	if optimize {
+		evaluator.Optimize(program)
+	}
+
+	if errs := evaluator.Resolve(program, env); len(errs) != 0 {
+		for _, msg := range errs {
+			fmt.Fprintf(os.Stderr, "\t%s\n", msg)
+		}
+		env.Streams().Flush()
+		object.Exit(1)
+	}
+}
 
-	evaluator.Eval(program, env)
+// lint reports the warnings evaluator.Lint gives for input on stderr,
+// failing if there are any.
+func lint(input string) int {
+	warnings := evaluator.Lint(Parse(input))
+	for _, msg := range warnings {
+		fmt.Fprintf(os.Stderr, "\t%s\n", msg)
+	}
+	if len(warnings) != 0 {
+		return 1
+	}
 	return 0
 }
 
+// mainFunction returns the main function defined at the top level of
+// program, which is called once the program has been evaluated.  Files
+// loaded by import() never have their main function called.
+func mainFunction(program *ast.Program) *ast.FunctionDefineLiteral {
+	for _, stmt := range program.Statements {
+		if es, ok := stmt.(*ast.ExpressionStatement); ok {
+			if def, ok := es.Expression.(*ast.FunctionDefineLiteral); ok && def.TokenLiteral() == "main" {
+				return def
+			}
+		}
+	}
+	return nil
+}
+
+// callMain calls main, passing it the script's arguments if it takes
+// any parameters: as an array, unless it has a rest parameter to collect
+// them.  An integer it returns becomes the exit status.  Whatever main
+// is bound to once the program has been evaluated is called, so that it
+// may be wrapped or replaced.
+func callMain(env *object.Environment) object.Object {
+	main, ok := env.Get("main")
+	if !ok {
+		return &object.Error{Message: "main is not defined"}
+	}
+	var args []object.Object
+	switch fn := main.(type) {
+	case *object.Function:
+		if fn.Variadic {
+			args = argsFun().(*object.Array).Elements
+		} else if len(fn.Parameters) > 0 {
+			args = append(args, argsFun())
+		}
+	case *object.Builtin:
+		args = append(args, argsFun())
+	}
+	return evaluator.Call(env, main, args...)
+}
+
+// scriptArguments returns the arguments following a script for the
+// script itself, dropping a leading "--" which only ends our own flags.
+func scriptArguments(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+	return args
+}
+
+// options holds the flags accepted both before a script and after the
+// run subcommand.
+type options struct {
+	eval        string
+	version     bool
+	trace       bool
+	traceJSON   bool
+	traceFilter string
+	tokens      bool
+	tree        bool
+	lint        bool
+	asJSON      bool
+	lines       bool
+	printLines  bool
+	fieldSep    string
+}
+
+func (o *options) define(fs *flag.FlagSet) {
+	fs.StringVar(&o.eval, "eval", "", "Code to execute.")
+	fs.StringVar(&o.eval, "e", "", "Shorthand for -eval.")
+	fs.BoolVar(&o.version, "version", false, "Show our version and exit.")
+	fs.BoolVar(&o.trace, "trace", false, "Trace statements, calls and assignments to stderr.")
+	fs.BoolVar(&o.traceJSON, "trace-json", false, "Emit the trace as JSON lines.")
+	fs.StringVar(&o.traceFilter, "trace-filter", "", "Only trace calls of the named function.")
+	fs.BoolVar(&o.tokens, "dump-tokens", false, "Print the token stream and exit.")
+	fs.BoolVar(&o.tree, "dump-ast", false, "Print the parsed program and exit.")
+	fs.BoolVar(&o.lint, "lint", false, "Report likely mistakes, such as switches which may match no case, and exit.")
+	fs.BoolVar(&o.asJSON, "json", false, "Use JSON for -dump-tokens and -dump-ast.")
+	fs.BoolVar(&o.lines, "n", false, "Run the program once for each line of input.")
+	fs.BoolVar(&o.printLines, "p", false, "Like -n, but print LINE after each run.")
+	fs.StringVar(&o.fieldSep, "F", "", "Regexp splitting each line into FIELDS; the default is whitespace.")
+	fs.BoolVar(&optimize, "optimize", false, "Fold constants and drop dead branches before running.")
+}
+
 func main() {
 
-	eval := flag.String("eval", "", "Code to execute.")
-	vers := flag.Bool("version", false, "Show our version and exit.")
+	if self, err := os.Executable(); err == nil {
+		if b, _, err := bundle.Open(self); err == nil && b != nil {
+			os.Exit(runBundle(b, os.Args[1:]))
+		}
+	}
 
-	flag.Parse()
+	os.Exit(command(os.Args[1:]))
+}
 
-	if *vers {
-		fmt.Printf("monkey %s\n", version)
-		os.Exit(1)
+// command runs the subcommand, or script, given by the command line
+// args, returning the status to exit with.
+func command(args []string) int {
+	opts := &options{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	opts.define(fs)
+	fs.Parse(args)
+
+	args = fs.Args()
+	if len(args) > 0 {
+		switch args[0] {
+		case "compile":
+			return compile(args[1:])
+		case "build":
+			return build(args[1:])
+		case "run", "watch":
+			fs := flag.NewFlagSet(args[0], flag.ExitOnError)
+			opts.define(fs)
+			fs.Parse(args[1:])
+			if args[0] == "watch" {
+				return watchScript(opts, fs.Args())
+			}
+			args = fs.Args()
+		}
 	}
 
-	if *eval != "" {
-		Execute(*eval)
-		os.Exit(1)
+	return run(opts, args)
+}
+
+// run executes the script named by the first of args, or given by
+// -eval, passing it the remaining arguments.  A script named "-", or no
+// script at all, is read from stdin.
+func run(opts *options, args []string) int {
+	if opts.version {
+		fmt.Printf("monkey %s\n", version)
+		return 0
 	}
 
 	var input []byte
 	var err error
 
-	if len(flag.Args()) > 0 {
-		input, err = ioutil.ReadFile(os.Args[1])
+	path := ""
+	if opts.eval != "" {
+		input = []byte(opts.eval)
 	} else {
-		input, err = ioutil.ReadAll(os.Stdin)
+		if len(args) > 0 {
+			path, args = args[0], args[1:]
+		}
+		if path == "" || path == "-" {
+			input, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			input, err = ioutil.ReadFile(path)
+		}
 	}
 
 	if err != nil {
-		fmt.Printf("Error reading: %s\n", err.Error())
+		fmt.Fprintf(os.Stderr, "Error reading: %s\n", err.Error())
+		return 1
+	}
+
+	dir := "."
+	if path != "" && path != "-" {
+		dir = filepath.Dir(path)
+	}
+	if err := loadConfig(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+		return 1
 	}
 
-	Execute(string(input))
+	// The command line overrides the config file.
+	if opts.trace || opts.traceJSON || opts.traceFilter != "" {
+		evaluator.PRAGMAS["trace"] = 1
+		evaluator.TRACE.JSON = opts.traceJSON
+		evaluator.TRACE.Filter = opts.traceFilter
+	}
+
+	args = scriptArguments(args)
+	scriptArgs = args
+
+	if opts.tokens {
+		return dumpTokens(os.Stdout, string(input), opts.asJSON)
+	}
+	if opts.tree {
+		return dumpAST(os.Stdout, string(input), opts.asJSON)
+	}
+	if opts.lint {
+		return lint(string(input))
+	}
+
+	var program *ast.Program
+	if path != "" && path != "-" {
+		evaluator.ScriptPath = path
+		if cached, ok := cache.Load(path, input); ok {
+			program = cached
+		}
+	}
+	if program == nil {
+		program = Parse(string(input))
+	}
+
+	if opts.lines || opts.printLines {
+		return runLines(program, opts, args)
+	}
+	return Run(program)
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMainFunction(t *testing.T) {
+	tests := []struct {
+		input    string
+		args     []string
+		status   int
+		expected string
+	}{
+		{`FUNC main(args) { PRINT(args); RETURN 3; }`, []string{"x", "y"}, 3, "[x, y]"},
+		{`FUNC main(first, ...rest) { PRINT(first, rest); }`, []string{"x", "y", "z"}, 0, "x[y, z]"},
+		{`FUNC main() { PRINT("main"); } PRINT("top ");`, nil, 0, "top main"},
+		{`FUNC main() { PRINT("original"); RETURN 1; }
+LET inner = main;
+LET main = FN(args) { PRINT("wrapped ", args, " "); inner(); RETURN 4; };`, []string{"a"}, 4, "wrapped [a] original"},
+		{`FUNC main() { LEN(1, 2); }`, nil, 1, ""},
+		{`FUNC main() { RETURN "not a status"; }`, nil, 0, ""},
+	}
+
+	for _, tt := range tests {
+		path := writeScript(t, t.TempDir(), "main.scream", tt.input)
+		status, out, _ := runCLI(t, &options{}, append([]string{path}, tt.args...), "")
+		if status != tt.status || out != tt.expected {
+			t.Errorf("got status %d and %q, want %d and %q for %s", status, out, tt.status, tt.expected, tt.input)
+		}
+	}
+}
+
+func TestMainNotCalledOnImport(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, "lib.scream", `FUNC main() { PRINT("lib main"); } FUNC helper() { "helped" }`)
+	path := writeScript(t, dir, "entry.scream", `import(__DIR__ + "/lib.scream"); PRINT(helper());`)
+	status, out, _ := runCLI(t, &options{}, []string{path}, "")
+	if status != 0 || out != "helped" {
+		t.Errorf("got status %d and %q", status, out)
+	}
+}
+
+func TestScriptLocation(t *testing.T) {
+	dir := t.TempDir()
+	path := writeScript(t, dir, "where.scream", `PRINT(__FILE__, "\n", __DIR__);`)
+	status, out, _ := runCLI(t, &options{}, []string{path}, "")
+	if want := path + "\n" + filepath.Dir(path); status != 0 || out != want {
+		t.Errorf("got status %d and %q, want %q", status, out, want)
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	writeScript(t, dir, ConfigFile, "# defaults for this directory\npragma = strict, no-trace\nSCREAM_TEST_GREETING = hello\n\nPATH = ignored\n")
+	path := writeScript(t, dir, "config.scream", `PRINT(pragma(), " ", os.getenv("SCREAM_TEST_GREETING"));`)
+	defer os.Unsetenv("SCREAM_TEST_GREETING")
+
+	path0 := os.Getenv("PATH")
+	status, out, _ := runCLI(t, &options{}, []string{path}, "")
+	if status != 0 || out != "[strict] hello" {
+		t.Errorf("got status %d and %q", status, out)
+	}
+	if os.Getenv("PATH") != path0 {
+		t.Errorf("scream.env replaced PATH")
+	}
+
+	// Flags given on the command line win over the file.  pragma()
+	// lists its names in no particular order.
+	status, out, errs := runCLI(t, &options{trace: true}, []string{path}, "")
+	if status != 0 || out != "[strict, trace] hello" && out != "[trace, strict] hello" || errs == "" {
+		t.Errorf("got status %d, %q and trace %q with -trace", status, out, errs)
+	}
+
+	writeScript(t, dir, ConfigFile, "not a setting\n")
+	if status, _, _ := runCLI(t, &options{}, []string{path}, ""); status != 1 {
+		t.Errorf("got status %d for a malformed %s", status, ConfigFile)
+	}
+}
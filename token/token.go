@@ -7,8 +7,10 @@ type Type string
 
 // Token struct represent the lexer token
 type Token struct {
-	Type    Type
-	Literal string
+	Type    Type   `json:"type"`
+	Literal string `json:"literal"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
 }
 
 // pre-defined Type
@@ -19,15 +21,22 @@ const (
 	ASTERISK_EQUALS = "*="
 	BACKTICK        = "`"
 	BANG            = "!"
+	BEGIN_INPUT     = "BEGIN_INPUT"
+	BREAK           = "BREAK"
 	CASE            = "case"
 	COLON           = ":"
 	COMMA           = ","
 	CONST           = "CONST"
+	CONTINUE        = "CONTINUE"
 	CONTAINS        = "~="
+	COUNTED_FOR     = "COUNTED_FOR"
 	DEFAULT         = "DEFAULT"
+	DEFER           = "DEFER"
 	DEFINE_FUNCTION = "DEFINE_FUNCTION"
 	DOTDOT          = ".."
+	ELLIPSIS        = "..."
 	ELSE            = "ELSE"
+	END_INPUT       = "END_INPUT"
 	EOF             = "EOF"
 	EQ              = "=="
 	FALSE           = "FALSE"
@@ -70,31 +79,41 @@ const (
 	SEMICOLON       = ";"
 	SLASH           = "/"
 	SLASH_EQUALS    = "/="
+	STEP            = "step"
 	STRING          = "STRING"
 	SWITCH          = "switch"
 	TRUE            = "TRUE"
+	YIELD           = "YIELD"
 )
 
 // reversed keywords
 var keywords = map[string]Type{
-	"case":    CASE,
-	"const":   CONST,
-	"default": DEFAULT,
-	"ELSE":    ELSE,
-	"FALSE":   FALSE,
-	"FN":      FUNCTION,
-	"WHILE":   FOR,
-	"foreach": FOREACH,
-	"FUNC":    DEFINE_FUNCTION,
-	"IF":      IF,
-	"in":      IN,
-	"LET":     LET,
-	"NIL":     NULL,
-	"RETURN":  RETURN,
-	"switch":  SWITCH,
-	"TRUE":    TRUE,
-	"BEGIN":   LBRACE,
-	"END":     RBRACE,
+	"case":     CASE,
+	"const":    CONST,
+	"default":  DEFAULT,
+	"ELSE":     ELSE,
+	"FALSE":    FALSE,
+	"FN":       FUNCTION,
+	"WHILE":    FOR,
+	"FOR":      COUNTED_FOR,
+	"BREAK":    BREAK,
+	"CONTINUE": CONTINUE,
+	"foreach":  FOREACH,
+	"FUNC":     DEFINE_FUNCTION,
+	"IF":       IF,
+	"in":       IN,
+	"LET":      LET,
+	"NIL":      NULL,
+	"RETURN":   RETURN,
+	"YIELD":    YIELD,
+	"DEFER":    DEFER,
+	"switch":   SWITCH,
+	"TRUE":     TRUE,
+	"BEGIN":    LBRACE,
+	"END":      RBRACE,
+
+	"BEGIN_INPUT": BEGIN_INPUT,
+	"END_INPUT":   END_INPUT,
 }
 
 // LookupIdentifier used to determinate whether identifier is keyword nor not
@@ -0,0 +1,116 @@
+package watch
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	// DefaultInterval is how often files are polled when Interval is zero.
+	DefaultInterval = 500 * time.Millisecond
+
+	// DefaultDebounce is used when Debounce is zero.
+	DefaultDebounce = 200 * time.Millisecond
+)
+
+// Clock tells the time, and lets tests control it.
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time        { return time.Now() }
+func (systemClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// SystemClock is the real time.
+var SystemClock Clock = systemClock{}
+
+// Watcher runs a script, then runs it again whenever any of the files it
+// loaded is modified.
+type Watcher struct {
+	// Run runs the script once, returning its exit status and the
+	// files it loaded, which are watched until the next run.
+	Run func() (status int, files []string)
+
+	Clock Clock
+
+	// Out receives the separator printed after each run.
+	Out io.Writer
+
+	// Interval is the time between polls.
+	Interval time.Duration
+
+	// Debounce is how long the files must have stayed unchanged before
+	// the script is run again, so that a burst of saves runs it once.
+	Debounce time.Duration
+
+	ran     bool
+	mtimes  map[string]time.Time
+	changed time.Time
+}
+
+// Watch runs the script and polls for changes forever.
+func (w *Watcher) Watch() {
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	for {
+		w.Step()
+		w.Clock.Sleep(interval)
+	}
+}
+
+// Step polls the watched files once, and runs the script if it has not
+// yet been run or if changes have settled.  It reports whether the script
+// was run.
+func (w *Watcher) Step() bool {
+	if !w.ran {
+		w.run()
+		return true
+	}
+
+	now := w.Clock.Now()
+	for path, mtime := range w.mtimes {
+		if current := modTime(path); !current.Equal(mtime) {
+			w.mtimes[path] = current
+			w.changed = now
+		}
+	}
+
+	debounce := w.Debounce
+	if debounce == 0 {
+		debounce = DefaultDebounce
+	}
+	if w.changed.IsZero() || now.Sub(w.changed) < debounce {
+		return false
+	}
+	w.run()
+	return true
+}
+
+func (w *Watcher) run() {
+	status, files := w.Run()
+	w.ran = true
+	w.changed = time.Time{}
+	w.mtimes = make(map[string]time.Time)
+	for _, path := range files {
+		w.mtimes[path] = modTime(path)
+	}
+	fmt.Fprintf(w.Out, "--- %s exit status %d ---\n", w.Clock.Now().Format("2006-01-02 15:04:05"), status)
+}
+
+// modTime returns the modification time of path, or the zero time if it
+// cannot be read, so that a file being created or removed counts as a
+// change.
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,94 @@
+package watch
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+
+func touch(t *testing.T, path string, mtime time.Time) {
+	if err := ioutil.WriteFile(path, []byte("PRINT(1);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "main.scream")
+	lib := filepath.Join(dir, "lib.scream")
+	base := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	touch(t, script, base)
+	touch(t, lib, base)
+
+	clock := &fakeClock{now: base}
+	out := &bytes.Buffer{}
+	runs := 0
+	w := &Watcher{
+		Run: func() (int, []string) {
+			runs++
+			return runs, []string{script, lib}
+		},
+		Clock:    clock,
+		Out:      out,
+		Interval: 100 * time.Millisecond,
+		Debounce: 300 * time.Millisecond,
+	}
+
+	if !w.Step() || runs != 1 {
+		t.Fatalf("first step did not run the script")
+	}
+	if !strings.Contains(out.String(), "2020-01-02 03:04:05 exit status 1") {
+		t.Errorf("unexpected separator %q", out.String())
+	}
+	clock.Sleep(time.Second)
+	if w.Step() {
+		t.Fatalf("ran with nothing changed")
+	}
+
+	// Two saves in quick succession run the script once, after the
+	// last has settled.
+	touch(t, lib, base.Add(time.Minute))
+	for i := 0; i < 2; i++ {
+		if w.Step() {
+			t.Fatalf("ran before changes settled")
+		}
+		clock.Sleep(200 * time.Millisecond)
+	}
+	touch(t, script, base.Add(2*time.Minute))
+	if w.Step() {
+		t.Fatalf("ran before changes settled")
+	}
+	clock.Sleep(200 * time.Millisecond)
+	if w.Step() {
+		t.Fatalf("ran before changes settled")
+	}
+	clock.Sleep(200 * time.Millisecond)
+	if !w.Step() || runs != 2 {
+		t.Fatalf("did not rerun after changes settled, runs=%d", runs)
+	}
+	if !strings.Contains(out.String(), "exit status 2") {
+		t.Errorf("unexpected separator %q", out.String())
+	}
+
+	// Removing a file is a change too.
+	os.Remove(lib)
+	w.Step()
+	clock.Sleep(time.Second)
+	if !w.Step() || runs != 3 {
+		t.Fatalf("did not rerun after removal, runs=%d", runs)
+	}
+}
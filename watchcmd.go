@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"scream/evaluator"
+	"scream/object"
+	"scream/watch"
+)
+
+// running is the run of a script which runOnce is waiting for, from
+// which the script's environment takes its streams and exit.
+var running *scriptRun
+
+// scriptRun keeps what one run of a script does apart from the next.
+// Tasks may outlive the run which started them, but once it has ended
+// their output is discarded and their calls to exit() are ignored.
+type scriptRun struct {
+	exits   chan int
+	streams *object.Streams
+
+	mu    sync.Mutex
+	ended bool
+}
+
+func newScriptRun() *scriptRun {
+	r := &scriptRun{exits: make(chan int, 1)}
+	r.streams = object.NewStreams(object.Stdio.Stdin, runOutput{r, false}, runOutput{r, true})
+	return r
+}
+
+// exit ends the goroutine calling it, passing code on to runOnce if it
+// is the first status given.
+func (r *scriptRun) exit(code int) {
+	select {
+	case r.exits <- code:
+	default:
+	}
+	runtime.Goexit()
+}
+
+func (r *scriptRun) end() {
+	r.mu.Lock()
+	r.ended = true
+	r.mu.Unlock()
+}
+
+// runOutput passes what a run writes on to object.Stdio until the run
+// has ended.
+type runOutput struct {
+	run      *scriptRun
+	diagnose bool
+}
+
+func (o runOutput) Write(p []byte) (int, error) {
+	o.run.mu.Lock()
+	defer o.run.mu.Unlock()
+	if !o.run.ended {
+		if o.diagnose {
+			object.Stdio.Diagnose(string(p))
+		} else {
+			object.Stdio.Print(string(p))
+		}
+	}
+	return len(p), nil
+}
+
+// watchScript runs the script named by the first of args, as run would,
+// and reruns it in a fresh environment whenever it or a file it loaded
+// changes.  It only returns if there is no script to watch.
+func watchScript(opts *options, args []string) int {
+	if len(args) == 0 || args[0] == "-" || opts.eval != "" {
+		fmt.Fprintf(os.Stderr, "watch needs the path of a script to run\n")
+		return 1
+	}
+
+	// Tasks left running by earlier runs may load files too.
+	var mu sync.Mutex
+	var files []string
+	evaluator.OnLoad = func(path string) {
+		mu.Lock()
+		files = append(files, path)
+		mu.Unlock()
+	}
+
+	w := &watch.Watcher{
+		Run: func() (int, []string) {
+			mu.Lock()
+			files = []string{args[0]}
+			mu.Unlock()
+			evaluator.Reset()
+			status := runOnce(opts, args)
+			object.Stdio.Flush()
+			mu.Lock()
+			defer mu.Unlock()
+			return status, append([]string(nil), files...)
+		},
+		Clock: watch.SystemClock,
+		Out:   os.Stderr,
+	}
+	w.Watch()
+	return 0
+}
+
+// runOnce calls run, returning the status the script exits with, either
+// by returning or by calling exit() on any goroutine.  A goroutine
+// calling exit() ends there.
+func runOnce(opts *options, args []string) int {
+	r := newScriptRun()
+	running = r
+	defer func() {
+		running = nil
+		r.end()
+	}()
+
+	done := make(chan int, 1)
+	go func() {
+		done <- run(opts, args)
+	}()
+	select {
+	case status := <-done:
+		// The script may have ended because a task called exit().
+		select {
+		case status := <-r.exits:
+			return status
+		default:
+			return status
+		}
+	case status := <-r.exits:
+		return status
+	}
+}